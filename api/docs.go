@@ -24,8 +24,101 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/rooms": {
+        "/healthz": {
+            "get": {
+                "description": "Answers as long as the process is able to serve requests. It doesn't check any dependency.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Metrics of the service in the Prometheus text format: HTTP requests by route, rooms, joins, votes, reveals, storage calls and rate limit rejections, plus those of the Go runtime.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Prometheus metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/openapi.json": {
+            "get": {
+                "description": "The OpenAPI 3.1 document of the API, with the security schemes, the codes of the errors and the events of the rooms.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenAPI"
+                ],
+                "summary": "OpenAPI document",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks that the Firestore is reachable, the configuration is valid and the background workers are running.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms": {
             "post": {
+                "description": "An anonymous room reveals its rounds as the cards played, shuffled, without who played them. A room with confidence asks every player how confident they are, from 1 to 5, with their card.",
                 "consumes": [
                     "application/json"
                 ],
@@ -69,7 +162,126 @@ var doc = `{
                 }
             }
         },
-        "/rooms/{pincode}/join": {
+        "/v1/rooms/{pincode}/events": {
+            "get": {
+                "description": "Server-Sent Events stream of what happens in the room from the moment of the subscription: the same events sent to the webhooks,\nwith the name of the event in \"event\" and the payload of the webhooks in \"data\". Votes are announced without their card.\nThe stream ends when the client falls behind, and when the server shuts down, after a server.shutdown event with a retry hint; clients are expected to reconnect.\nA player who follows the stream with their ID and token is online while it is open.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Rooms"
+                ],
+                "summary": "Subscribe to the events of a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the player following the stream",
+                        "name": "player_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer token of the player, with player_id",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The data of each message",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/export": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/markdown"
+                ],
+                "tags": [
+                    "Rooms"
+                ],
+                "summary": "Export the results of a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "md"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/join": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -127,8 +339,9 @@ var doc = `{
                 }
             }
         },
-        "/rooms/{pincode}/players": {
+        "/v1/rooms/{pincode}/players": {
             "get": {
+                "description": "The players come in pages, linked by the Link header with rel=\"next\" until the last one.\nThe ETag is the version of the room, bumped on every change of its players, rounds or votes.\nWith If-None-Match, or since, the players are only sent when the room changed; otherwise the answer is 304.\nWith wait too, the request is held until the room changes, up to a minute.",
                 "produces": [
                     "application/json"
                 ],
@@ -143,6 +356,81 @@ var doc = `{
                         "name": "pincode",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Players per page, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Where the page starts, from the Link of the previous one",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "joined_at",
+                            "name"
+                        ],
+                        "type": "string",
+                        "default": "joined_at",
+                        "description": "Order of the players",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Direction of the order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "voter",
+                            "observer"
+                        ],
+                        "type": "string",
+                        "description": "Only the players with the role",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only the players following the events of the room, or the others",
+                        "name": "online",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only the players who voted in the latest round, or who didn't",
+                        "name": "voted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the players the client has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version of the room the client has, as in the ETag",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "How long to wait for a change, e.g. 30s",
+                        "name": "wait",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -153,6 +441,25 @@ var doc = `{
                             "items": {
                                 "$ref": "#/definitions/players.Player"
                             }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the room, as a quoted number"
+                            },
+                            "Link": {
+                                "type": "string",
+                                "description": "The next page"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
                         }
                     },
                     "404": {
@@ -169,76 +476,1571 @@ var doc = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Error": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "players.Player": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "joined_at": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "rooms.Room": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "pincode": {
-                    "type": "string"
-                }
-            }
-        },
-        "rooms.RoomJoinRequest": {
-            "type": "object",
-            "properties": {
-                "player_name": {
-                    "type": "string"
-                }
-            }
         },
-        "rooms.RoomJoinResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "room": {
-                    "$ref": "#/definitions/rooms.Room"
-                }
+        "/v1/rooms/{pincode}/polls": {
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Opens a quick decision in the room, apart from the rounds: a single choice, a multi choice where every player places dots, or a yes/no/abstain poll. Only the facilitator can open polls.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Polls"
+                ],
+                "summary": "Open a poll",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The poll",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/polls.PollNewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/polls.Poll"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/polls/{id}": {
+            "get": {
+                "description": "How many players voted in the poll and, once it is closed, its results.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Polls"
+                ],
+                "summary": "Get a poll",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the Poll",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/polls.Poll"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/polls/{id}/close": {
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Ends the votes of a poll and shows its results. Only the facilitator can close polls.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Polls"
+                ],
+                "summary": "Close a poll",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the Poll",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/polls.Poll"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/polls/{id}/votes": {
+            "post": {
+                "security": [
+                    {
+                        "PlayerToken": []
+                    }
+                ],
+                "description": "Records the choices of a player, replacing the previous ones while the poll is open: one option, or one per dot in a multi choice poll.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Polls"
+                ],
+                "summary": "Vote in a poll",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the Poll",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The choices",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/polls.PollVoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/rounds": {
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Opens the next round of the room, for a story or for none. Only the facilitator can start rounds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rounds"
+                ],
+                "summary": "Start a round",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Story of the round",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/rounds.RoundNewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rounds.Round"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/rounds/{n}/card": {
+            "get": {
+                "description": "Renders a revealed round as an Adaptive Card (Microsoft Teams, bots) or as Markdown, ready to be posted to a chat.",
+                "produces": [
+                    "application/json",
+                    "text/markdown"
+                ],
+                "tags": [
+                    "Rooms"
+                ],
+                "summary": "Get the result card of a round",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of the Round",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "adaptive",
+                            "markdown"
+                        ],
+                        "type": "string",
+                        "default": "adaptive",
+                        "description": "Card format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cards.AdaptiveCard"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/rounds/{n}/reveal": {
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Shows the votes of a round. Only the facilitator can reveal rounds. The rounds of an anonymous room only show their cards.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rounds"
+                ],
+                "summary": "Reveal a round",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of the Round",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rounds.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/rounds/{n}/revote": {
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Opens the next attempt at the story of a revealed round, once its votes were discussed. The votes of the round are kept as the previous attempt, and the new round tells how long the discussion took. Only the facilitator can start re-votes, and only on the latest round.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rounds"
+                ],
+                "summary": "Vote again on a round",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of the Round",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rounds.Round"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/rounds/{n}/votes": {
+            "post": {
+                "security": [
+                    {
+                        "PlayerToken": []
+                    }
+                ],
+                "description": "Records the card of a player, replacing the previous one while the round is hidden. The rounds of an anonymous room keep the card apart from the player, so their votes can't be replaced. The rooms that ask for confidence need one from 1 to 5 with the card; the others refuse it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rounds"
+                ],
+                "summary": "Vote in a round",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of the Round",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The vote",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rounds.VoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/stories": {
+            "get": {
+                "description": "The stories come in pages, linked by the Link header with rel=\"next\" until the last one.\nWith estimated=true, a page can hold fewer stories than the limit, even none, before the last one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "List the stories of a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Stories per page, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Where the page starts, from the Link of the previous one",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "key",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Order of the stories",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Direction of the order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only the stories with an estimate, or without one",
+                        "name": "estimated",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/stories.Story"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "The next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/stories/import": {
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Bulk-creates stories from a CSV file (title, key, link, description) or from a Jira/GitHub issues JSON export.\nStories are identified by their key, so uploading the same file again updates them instead of creating duplicates.",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/csv",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Import stories into a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "jira",
+                            "github"
+                        ],
+                        "type": "string",
+                        "description": "Format of the file, detected from its content when omitted",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "title",
+                        "description": "CSV column holding the title",
+                        "name": "title_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "key",
+                        "description": "CSV column holding the key",
+                        "name": "key_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "link",
+                        "description": "CSV column holding the link",
+                        "name": "link_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "description",
+                        "description": "CSV column holding the description",
+                        "name": "description_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to import, when sent as multipart/form-data",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stories.StoryImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/stories/{id}/estimate": {
+            "put": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "Sets the final estimate of a story, replacing the previous one. Only the facilitator can estimate stories.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Estimate a story",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the Story",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The estimate",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/stories.StoryEstimateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stories.Story"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get the webhooks of a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhooks.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "description": "The events are POSTed as JSON, signed with the returned secret in the X-ScrumPoker-Signature header. The url must not point to a private address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Register a webhook in a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook to register",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.WebhookNewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.WebhookNewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/webhooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get the latest webhook deliveries of a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhooks.Delivery"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rooms/{pincode}/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "FacilitatorToken": []
+                    }
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Remove a webhook from a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pin Code of the Room",
+                        "name": "pincode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the Webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/slack/commands": {
+            "post": {
+                "description": "Handles ` + "`" + `/poker \u003cstory\u003e` + "`" + `: creates a room for the story and posts a message with one button per card.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Slack"
+                ],
+                "summary": "Slack slash command",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Signature of the request",
+                        "name": "X-Slack-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timestamp of the request",
+                        "name": "X-Slack-Request-Timestamp",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slack.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/slack/interactions": {
+            "post": {
+                "description": "Handles the buttons of the voting message: a card records the vote of the user, \"Reveal\" shows the results.\nOnly the user who created the room can reveal, and only the rooms created in Slack are played there.\nThe message is updated through the response_url of the interaction.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "tags": [
+                    "Slack"
+                ],
+                "summary": "Slack interactive messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Signature of the request",
+                        "name": "X-Slack-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timestamp of the request",
+                        "name": "X-Slack-Request-Timestamp",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "cards.AdaptiveCard": {
+            "type": "object",
+            "properties": {
+                "$schema": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/cards.Element"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "cards.Element": {
+            "type": "object",
+            "properties": {
+                "facts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/cards.Fact"
+                    }
+                },
+                "isSubtle": {
+                    "type": "boolean"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "spacing": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "string"
+                },
+                "wrap": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "cards.Fact": {
+            "type": "object",
+            "properties": {
+                "title": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pincode": {
+                    "type": "string"
+                },
+                "room_id": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Check": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Response": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/health.Check"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "HTTP status",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Stable code of the error, e.g. room_not_found",
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Fields that failed validation",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "ID of the request, to find it in the logs",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "players.Player": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "description": "Whether the player follows the events of the room",
+                    "type": "boolean"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "voted": {
+                    "description": "Whether the player voted in the latest round of the room",
+                    "type": "boolean"
+                }
+            }
+        },
+        "polls.Poll": {
+            "type": "object",
+            "properties": {
+                "closed": {
+                    "type": "boolean"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dots": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "results": {
+                    "description": "The options with their votes, in the order of the options, once the\npoll is closed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/polls.Result"
+                    }
+                },
+                "voters": {
+                    "description": "How many players voted",
+                    "type": "integer"
+                }
+            }
+        },
+        "polls.PollNewRequest": {
+            "type": "object",
+            "properties": {
+                "dots": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                }
+            }
+        },
+        "polls.PollVoteRequest": {
+            "type": "object",
+            "properties": {
+                "choices": {
+                    "description": "The chosen options; a multi choice poll takes one per dot",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "player_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "polls.Result": {
+            "type": "object",
+            "properties": {
+                "option": {
+                    "type": "string"
+                },
+                "votes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rooms.Room": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "The revealed votes only show the cards, shuffled, without who played\nthem",
+                    "type": "boolean"
+                },
+                "confidence": {
+                    "description": "Every vote comes with how confident the player is, from 1 to 5, as\nwith a fist of five",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pincode": {
+                    "type": "string"
+                }
+            }
+        },
+        "rooms.RoomJoinRequest": {
+            "type": "object",
+            "properties": {
+                "player_name": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "voter, the default, or observer",
+                    "type": "string"
+                }
+            }
+        },
+        "rooms.RoomJoinResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "player_token": {
+                    "description": "Authorizes the votes of the player. It is only shown once.",
+                    "type": "string"
+                },
+                "room": {
+                    "$ref": "#/definitions/rooms.Room"
+                }
             }
         },
         "rooms.RoomNewRequest": {
             "type": "object",
             "properties": {
+                "anonymous": {
+                    "description": "The revealed votes only show the cards, shuffled, without who played\nthem",
+                    "type": "boolean"
+                },
+                "confidence": {
+                    "description": "Every vote comes with how confident the player is, from 1 to 5, as\nwith a fist of five",
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string"
                 }
@@ -247,6 +2049,9 @@ var doc = `{
         "rooms.RoomNewResponse": {
             "type": "object",
             "properties": {
+                "facilitator_token": {
+                    "type": "string"
+                },
                 "pincode": {
                     "type": "string"
                 },
@@ -254,6 +2059,387 @@ var doc = `{
                     "type": "string"
                 }
             }
+        },
+        "rounds.Confidence": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "description": "Absent when nobody voted",
+                    "type": "number"
+                },
+                "distribution": {
+                    "description": "Count of each level, from \"1\" to \"5\"",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "lowest": {
+                    "description": "The lowest level, which is where the discussion should start",
+                    "type": "integer"
+                }
+            }
+        },
+        "rounds.Round": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "Whether the room was anonymous when the round started. Such a round\nhas no Votes: it keeps who voted and, apart, the sorted cards, so no\ncard is ever stored with its player.",
+                    "type": "boolean"
+                },
+                "attempt": {
+                    "description": "Attempt at estimating the story, from 1. A re-vote is the next attempt\nof the round before it, which keeps its votes.",
+                    "type": "integer"
+                },
+                "confidence": {
+                    "description": "Whether the room asked for a confidence with every vote when the round\nstarted. The confidences are kept by player, or apart like the cards\nin an anonymous round.",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discussion_seconds": {
+                    "description": "Seconds from the reveal of the previous attempt to the re-vote",
+                    "type": "number"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "revealed": {
+                    "type": "boolean"
+                },
+                "revealed_at": {
+                    "type": "string"
+                },
+                "story_id": {
+                    "type": "string"
+                },
+                "votes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "rounds.RoundNewRequest": {
+            "type": "object",
+            "properties": {
+                "story_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "rounds.Summary": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "type": "boolean"
+                },
+                "attempt": {
+                    "type": "integer"
+                },
+                "average": {
+                    "description": "Average of the numeric votes, absent when there are none",
+                    "type": "number"
+                },
+                "cards": {
+                    "description": "The cards of an anonymous round, shuffled",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "confidence": {
+                    "description": "Absent unless the room asks for confidence",
+                    "$ref": "#/definitions/rounds.Confidence"
+                },
+                "consensus": {
+                    "type": "boolean"
+                },
+                "distribution": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "story_id": {
+                    "type": "string"
+                },
+                "story_title": {
+                    "type": "string"
+                },
+                "votes": {
+                    "description": "Empty when the round is anonymous",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rounds.Vote"
+                    }
+                }
+            }
+        },
+        "rounds.Vote": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "type": "integer"
+                },
+                "player_id": {
+                    "type": "string"
+                },
+                "player_name": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "rounds.VoteRequest": {
+            "type": "object",
+            "properties": {
+                "card": {
+                    "type": "string"
+                },
+                "confidence": {
+                    "description": "From 1 to 5, only in the rooms that ask for it",
+                    "type": "integer"
+                },
+                "player_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "slack.Block": {
+            "type": "object",
+            "properties": {
+                "block_id": {
+                    "type": "string"
+                },
+                "elements": {
+                    "description": "Buttons in actions blocks, texts in context blocks",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Text"
+                    }
+                },
+                "text": {
+                    "$ref": "#/definitions/slack.Text"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "slack.Message": {
+            "type": "object",
+            "properties": {
+                "blocks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slack.Block"
+                    }
+                },
+                "replace_original": {
+                    "type": "boolean"
+                },
+                "response_type": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "slack.Text": {
+            "type": "object",
+            "properties": {
+                "emoji": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "stories.Story": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "estimate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "stories.StoryEstimateRequest": {
+            "type": "object",
+            "properties": {
+                "estimate": {
+                    "type": "string"
+                }
+            }
+        },
+        "stories.StoryImportError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "stories.StoryImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stories.StoryImportError"
+                    }
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webhooks.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.WebhookNewRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.WebhookNewResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "The secret used to sign the payloads. It is only shown once.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "FacilitatorToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "PlayerToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/logging"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"golang.org/x/net/nettest"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlowStorageTimesOut(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	// A storage that accepts connections and never answers
+	listener, err := nettest.NewLocalListener("tcp")
+	assert.NoError(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	conf := config.Default()
+	conf.Firestore.ProjectId = "scrumpoker"
+	conf.Firestore.EmulatorHost = listener.Addr().String()
+	conf.Timeouts.Request = 200 * time.Millisecond
+	assert.NoError(di.SetupDependencies(conf))
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	SetupRouter(app, conf, logging.New(ioutil.Discard, logging.Error))
+
+	start := time.Now()
+	res, err := app.Test(httptest.NewRequest("GET", "/rooms/123456/players", nil), 10000)
+	assert.NoError(err)
+	assert.Less(int64(time.Since(start)), int64(5*time.Second))
+
+	body, _ := ioutil.ReadAll(res.Body)
+	var result models.Error
+	assert.NoError(json.Unmarshal(body, &result))
+	assert.Equal(504, result.Code)
+	assert.Equal("the storage took too long to answer", result.Message)
+}
@@ -1,56 +1,161 @@
 package main
 
 import (
-	"cloud.google.com/go/firestore"
+	"context"
+	"flag"
 	"fmt"
 	swagger "github.com/arsmn/fiber-swagger/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/golobby/container"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	_ "github.com/thiagopereiramartinez/scrumpoker-run.api/api"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/health"
+	metricsController "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/metrics"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/openapi"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/slack"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/deadline"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/deprecation"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
-	"log"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/logging"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/tracing"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // @title Scrum Poker API
 // @version 1.0
+// @securityDefinitions.apikey FacilitatorToken
+// @in header
+// @name Authorization
+// @securityDefinitions.apikey PlayerToken
+// @in header
+// @name Authorization
 func main() {
 
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON configuration file")
+	printConfig := flag.Bool("print-config", false, "print the configuration, with secrets redacted, and exit")
+	flag.Parse()
+
+	// Load the configuration
+	conf, err := config.Load(*configFile, os.LookupEnv)
+	if err != nil {
+		fatal(logging.New(os.Stderr, logging.Info), "could not load the configuration", err)
+	}
+
+	if *printConfig {
+		fmt.Print(conf)
+		return
+	}
+
+	// Setup Logging
+	level, _ := logging.ParseLevel(conf.Log.Level)
+	logger := logging.New(os.Stdout, level)
+
 	// Setup Dependency Injection
-	if err := di.SetupDependencies(); err != nil {
-		log.Fatalln(err)
+	if err := di.SetupDependencies(conf); err != nil {
+		fatal(logger, "could not setup the dependencies", err)
 	}
 
 	// Create Fiber App
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  conf.Timeouts.Read,
+		WriteTimeout: conf.Timeouts.Write,
+		IdleTimeout:  conf.Timeouts.Idle,
+		ErrorHandler: utils.ErrorHandler,
+	})
 
 	// Configure Router
-	SetupRouter(app)
+	SetupRouter(app, conf, logger)
 
 	// Initialize Fiber App
-	port := os.Getenv("PORT")
-	if len(port) == 0 {
-		port = "8080"
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%s", conf.Port))
+	}()
+
+	// Wait for Cloud Run (SIGTERM) or Ctrl+C (SIGINT)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-listenErr:
+		fatal(logger, "could not start the server", err)
+	case <-quit:
 	}
 
-	if err := app.Listen(fmt.Sprintf(":%s", port)); err != nil {
-		log.Fatalln(err)
+	logger.Info("shutting down", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeouts.Shutdown)
+	defer cancel()
+
+	if err := Shutdown(ctx, app); err != nil {
+		fatal(logger, "could not shut down cleanly", err)
 	}
+}
 
-	defer func() {
-		// Encerrar conexão com o Firestore
-		db := new(firestore.Client)
-		container.Make(&db)
-		db.Close()
-	}()
+func fatal(logger *logging.Logger, msg string, err error) {
+	logger.Error(msg, logging.Fields{"error": err})
+	os.Exit(1)
 }
 
-func SetupRouter(app fiber.Router) {
+// ApiVersion prefixes the routes of the API
+const ApiVersion = "/v1"
+
+// When the routes without a version were deprecated, in favor of /v1
+var legacyRoutesDeprecated = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+func SetupRouter(app fiber.Router, conf *config.Config, logger *logging.Logger) {
+
+	// Setup Request ID, Tracing and Metrics
+	app.Use(requestid.New(requestid.Config{
+		ContextKey: utils.LocalRequestId,
+	}))
+	app.Use(tracing.NewMiddleware())
+	app.Use(metrics.New())
+
+	// Setup Logging, which also answers the errors of the handlers, and the
+	// recovery of their panics
+	app.Use(logging.NewMiddleware(logger))
+	app.Use(logging.NewRecover())
+
+	// Setup the deadline of the requests
+	app.Use(deadline.New(conf.Timeouts.Request))
 
 	// Setup CORS
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		AllowOrigins: strings.Join(conf.Cors.AllowOrigins, ","),
+		// The version of the room, for the long polls of the browsers, and
+		// the next pages of the listings
+		ExposeHeaders: fiber.HeaderETag + ", " + fiber.HeaderLink,
+	}))
+
+	// Register "health" and "metrics", before the rate limit so the probes
+	// and the scraper never hit it
+	health.Register(app)
+	metricsController.Register(app)
+
+	// Setup Rate Limit
+	if conf.RateLimit.Max > 0 {
+		app.Use(limiter.New(limiter.Config{
+			Max:        conf.RateLimit.Max,
+			Expiration: conf.RateLimit.Window,
+			LimitReached: func(c *fiber.Ctx) error {
+				metrics.RateLimited.Inc()
+				return apierror.New(apierror.RateLimited, "too many requests")
+			},
+		}))
+	}
 
 	// Setup Swagger
 	app.Get("/swagger", func(ctx *fiber.Ctx) error {
@@ -58,7 +163,40 @@ func SetupRouter(app fiber.Router) {
 	})
 	app.Get("/swagger/*", swagger.Handler)
 
+	// Serve the OpenAPI 3.1 document
+	openapi.Register(app)
+
+	// Register the API under /v1
+	RegisterApi(app.Group(ApiVersion))
+
+	// And without the version, as before /v1, marked as deprecated
+	if conf.LegacyRoutes.Enabled {
+		legacy := deprecation.New(deprecation.Config{
+			Since:     legacyRoutesDeprecated,
+			Sunset:    conf.LegacyRoutes.Sunset,
+			Successor: ApiVersion,
+		})
+		for _, prefix := range []string{"/rooms", "/slack"} {
+			app.Use(prefix, legacy)
+		}
+		RegisterApi(app)
+	}
+
+}
+
+// RegisterApi registers the controllers of the API.
+func RegisterApi(router fiber.Router) {
+
 	// Register "rooms"
-	rooms.Register(app)
+	rooms.Register(router)
+
+	// Register "stories"
+	stories.Register(router)
+
+	// Register "webhooks"
+	webhooks.Register(router)
+
+	// Register "slack"
+	slack.Register(router)
 
 }
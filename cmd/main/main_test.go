@@ -3,7 +3,10 @@ package main
 import (
 	Assert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/logging"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"io/ioutil"
 	"testing"
 )
 
@@ -15,9 +18,14 @@ func TestSetupRouter(t *testing.T) {
 	router.On("Use", mock.Anything).Return(router)
 	router.On("Get", mock.Anything, mock.Anything).Return(router)
 	router.On("Post", mock.Anything, mock.Anything).Return(router)
+	router.On("Put", mock.Anything, mock.Anything).Return(router)
+	router.On("Delete", mock.Anything, mock.Anything).Return(router)
 	router.On("Group", mock.Anything, mock.Anything).Return(router)
 
-	SetupRouter(router)
+	conf := config.Default()
+	conf.RateLimit.Max = 100
+
+	SetupRouter(router, conf, logging.New(ioutil.Discard, logging.Info))
 
 	router.AssertExpectations(t)
 	Assert.True(t, len(router.Mock.Calls) > 0)
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/openapi"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var routeParam = regexp.MustCompile(`:(\w+)\??`)
+
+// TestOpenApiCoversTheRoutes fails when a route is served without being in
+// the OpenAPI document. The middlewares, the Swagger UI and the deprecated
+// routes without the version are not part of it.
+func TestOpenApiCoversTheRoutes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := newRoutesApp(config.Default())
+
+	doc, err := openapi.Document()
+	if !assert.NoError(err) {
+		return
+	}
+	spec := new(struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	})
+	assert.NoError(json.Unmarshal(doc, spec))
+
+	// The middlewares are in the stacks of every method, TRACE included,
+	// which no route uses
+	stacks := map[string]map[string]bool{}
+	for _, routes := range app.Stack() {
+		for _, route := range routes {
+			if stacks[route.Method] == nil {
+				stacks[route.Method] = map[string]bool{}
+			}
+			stacks[route.Method][route.Path] = true
+		}
+	}
+
+	checked := 0
+	for method, paths := range stacks {
+		if method == "HEAD" || method == "TRACE" {
+			continue
+		}
+		for path := range paths {
+			switch {
+			case stacks["TRACE"][path]:
+			case path == "/swagger" || strings.HasPrefix(path, "/swagger/"):
+			case !strings.HasPrefix(path, ApiVersion+"/") && paths[ApiVersion+path]:
+			default:
+				specPath := routeParam.ReplaceAllString(path, "{$1}")
+				_, ok := spec.Paths[specPath][strings.ToLower(method)]
+				assert.True(ok, "%s %s is not in the OpenAPI document", method, specPath)
+				checked++
+			}
+		}
+	}
+	assert.Greater(checked, 10)
+}
+
+func TestServeOpenApi(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := newRoutesApp(config.Default())
+
+	res, err := app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	doc := make(map[string]interface{})
+	assert.NoError(json.NewDecoder(res.Body).Decode(&doc))
+	assert.Equal("3.1.0", doc["openapi"])
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/deprecation"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/logging"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newRoutesApp(conf *config.Config) *fiber.App {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	SetupRouter(app, conf, logging.New(ioutil.Discard, logging.Error))
+	return app
+}
+
+// postInvalidRoom creates a room without a name, which is answered before
+// reaching the storage.
+func postInvalidRoom(assert *Assert.Assertions, app *fiber.App, path string) map[string][]string {
+	req := httptest.NewRequest("POST", path, bytes.NewBufferString(`{"name": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := app.Test(req)
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	return res.Header
+}
+
+func TestVersionedRoutes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf := config.Default()
+	conf.LegacyRoutes.Sunset = time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+	app := newRoutesApp(conf)
+
+	header := postInvalidRoom(assert, app, "/v1/rooms")
+	assert.Empty(header[deprecation.HeaderDeprecation])
+
+	header = postInvalidRoom(assert, app, "/rooms")
+	assert.Equal([]string{"@1792368000"}, header[deprecation.HeaderDeprecation])
+	assert.Equal([]string{"Thu, 01 Apr 2027 00:00:00 GMT"}, header[deprecation.HeaderSunset])
+	assert.Equal([]string{`</v1/rooms>; rel="successor-version"`}, header[deprecation.HeaderLink])
+}
+
+func TestLegacyRoutesDisabled(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf := config.Default()
+	conf.LegacyRoutes.Enabled = false
+	app := newRoutesApp(conf)
+
+	postInvalidRoom(assert, app, "/v1/rooms")
+
+	res, err := app.Test(httptest.NewRequest("POST", "/rooms", nil))
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Shutdown stops the service in order: it ends the streams of events, stops
+// accepting connections and waits for the requests in flight, then for the
+// webhooks being delivered and the spans being exported, and only then closes
+// the Firestore client they use. Whatever is still running when ctx expires is
+// abandoned, but every step is taken anyway; the first error is returned.
+func Shutdown(ctx context.Context, app *fiber.App) error {
+
+	var first error
+	record := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+
+	// End the streams of events, which would never drain
+	broker := new(events.Broker)
+	container.Make(&broker)
+	broker.Close()
+
+	// Drain the requests
+	drained := make(chan error, 1)
+	go func() {
+		drained <- app.Shutdown()
+	}()
+
+	select {
+	case err := <-drained:
+		record(err)
+	case <-ctx.Done():
+		record(ctx.Err())
+	}
+
+	// Stop the background jobs
+	dispatcher := new(webhooks.Dispatcher)
+	container.Make(&dispatcher)
+	record(dispatcher.Shutdown(ctx))
+
+	// Export the last spans
+	provider := new(sdktrace.TracerProvider)
+	container.Make(&provider)
+	record(provider.Shutdown(ctx))
+
+	// Close the connection with the Firestore
+	db := new(firestore.Client)
+	container.Make(&db)
+	record(db.Close())
+
+	return first
+}
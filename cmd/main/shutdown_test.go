@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"golang.org/x/net/nettest"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// startApp serves a route that only answers once release is closed, and
+// tells through started when a request arrived.
+func startApp(assert *Assert.Assertions) (app *fiber.App, url string, started chan struct{}, release chan struct{}) {
+	conf := config.Default()
+	conf.Firestore.ProjectId = "scrumpoker"
+	conf.Firestore.EmulatorHost = os.Getenv("FIRESTORE_EMULATOR_HOST")
+	if len(conf.Firestore.EmulatorHost) == 0 {
+		// The client connects lazily, so it doesn't need to be reachable
+		conf.Firestore.EmulatorHost = "127.0.0.1:1"
+	}
+	assert.NoError(di.SetupDependencies(conf))
+
+	started = make(chan struct{})
+	release = make(chan struct{})
+
+	app = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendString("done")
+	})
+
+	listener, err := nettest.NewLocalListener("tcp")
+	assert.NoError(err)
+	go func() {
+		_ = app.Listener(listener)
+	}()
+
+	return app, fmt.Sprintf("http://%s/slow", listener.Addr()), started, release
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+
+	assert := Assert.New(t)
+	app, url, started, release := startApp(assert)
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get(url)
+		assert.NoError(err)
+		responses <- res
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- Shutdown(context.Background(), app)
+	}()
+
+	select {
+	case <-shutdown:
+		assert.Fail("the shutdown didn't wait for the request in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	assert.NoError(<-shutdown)
+	res := <-responses
+	if assert.NotNil(res) {
+		assert.Equal(200, res.StatusCode)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+
+	assert := Assert.New(t)
+	app, url, started, release := startApp(assert)
+	defer close(release)
+
+	go func() {
+		_, _ = http.Get(url)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(context.DeadlineExceeded, Shutdown(ctx, app))
+
+	// The rest was stopped anyway
+	dispatcher := new(webhooks.Dispatcher)
+	container.Make(&dispatcher)
+	assert.False(dispatcher.Running())
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+
+	assert := Assert.New(t)
+	app, _, _, release := startApp(assert)
+	defer close(release)
+
+	broker := new(events.Broker)
+	container.Make(&broker)
+	stream, _ := broker.Subscribe("room-1")
+
+	assert.NoError(Shutdown(context.Background(), app))
+
+	event := <-stream
+	assert.Equal(events.EventShutdown, event.Event)
+	_, open := <-stream
+	assert.False(open)
+}
@@ -0,0 +1,156 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strings"
+)
+
+// Code identifies the kind of an error. Unlike the messages, codes are stable
+// and meant for clients to branch on.
+type Code string
+
+const (
+	RoomNotFound     Code = "room_not_found"
+	NotFound         Code = "not_found"
+	MethodNotAllowed Code = "method_not_allowed"
+	InvalidBody      Code = "invalid_body"
+	ValidationFailed Code = "validation_failed"
+	RoomClosed       Code = "room_closed"
+	Conflict         Code = "conflict"
+	Unauthorized     Code = "unauthorized"
+	RateLimited      Code = "rate_limited"
+	Timeout          Code = "timeout"
+	Canceled         Code = "canceled"
+	Internal         Code = "internal"
+)
+
+// StatusClientClosedRequest answers requests given up by the client (the
+// status nginx uses for it)
+const StatusClientClosedRequest = 499
+
+var statuses = map[Code]int{
+	RoomNotFound:     404,
+	NotFound:         404,
+	MethodNotAllowed: 405,
+	InvalidBody:      400,
+	ValidationFailed: 400,
+	RoomClosed:       409,
+	Conflict:         409,
+	Unauthorized:     401,
+	RateLimited:      429,
+	Timeout:          504,
+	Canceled:         StatusClientClosedRequest,
+	Internal:         500,
+}
+
+// Codes lists the codes, sorted.
+func Codes() []Code {
+	codes := make([]Code, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i] < codes[j]
+	})
+	return codes
+}
+
+// Status is the HTTP status that answers the code.
+func (code Code) Status() int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return 500
+}
+
+// Error is an error with a code, and the fields at fault when the request
+// failed validation.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []models.FieldError
+	err     error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap gives err a code, keeping its message.
+func Wrap(code Code, err error) *Error {
+	return &Error{Code: code, Message: err.Error(), err: err}
+}
+
+// Invalid reports a field of the request that failed validation.
+func Invalid(field, message string) *Error {
+	return Validation([]models.FieldError{{Field: field, Message: message}})
+}
+
+// Validation reports all the fields of the request that failed validation.
+func Validation(fields []models.FieldError) *Error {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+
+	return &Error{
+		Code:    ValidationFailed,
+		Message: strings.Join(messages, "; "),
+		Fields:  fields,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// FromStatus gives a code to the errors that only have an HTTP status, like
+// the ones of Fiber.
+func FromStatus(status int, message string) *Error {
+	switch {
+	case status == 404:
+		return New(NotFound, message)
+	case status == 405:
+		return New(MethodNotAllowed, message)
+	case status == 401 || status == 403:
+		return New(Unauthorized, message)
+	case status == 408 || status == 504:
+		return New(Timeout, message)
+	case status == 429:
+		return New(RateLimited, message)
+	case status >= 400 && status < 500:
+		return New(InvalidBody, message)
+	}
+	return New(Internal, message)
+}
+
+// From finds the Error in err. Calls cut short by their deadline or canceled
+// become Timeout and Canceled, and anything else Internal.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded:
+		return &Error{Code: Timeout, Message: "the storage took too long to answer", err: err}
+	case errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled:
+		return &Error{Code: Canceled, Message: "the request was canceled", err: err}
+	}
+
+	return Wrap(Internal, err)
+}
@@ -0,0 +1,67 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	assert.Equal(404, RoomNotFound.Status())
+	assert.Equal(400, InvalidBody.Status())
+	assert.Equal(400, ValidationFailed.Status())
+	assert.Equal(409, RoomClosed.Status())
+	assert.Equal(401, Unauthorized.Status())
+	assert.Equal(429, RateLimited.Status())
+	assert.Equal(500, Code("unknown").Status())
+}
+
+func TestCodes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	codes := Codes()
+
+	assert.Len(codes, 12)
+	assert.Equal(Canceled, codes[0])
+	assert.Contains(codes, RoomNotFound)
+}
+
+func TestInvalid(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	err := Invalid("name", "the name of the room is required")
+
+	assert.Equal(ValidationFailed, err.Code)
+	assert.Equal("the name of the room is required", err.Error())
+	assert.Equal([]models.FieldError{{Field: "name", Message: "the name of the room is required"}}, err.Fields)
+}
+
+func TestFrom(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	notFound := New(RoomNotFound, "room not found")
+	assert.Same(notFound, From(notFound))
+	assert.Same(notFound, From(fmt.Errorf("joining: %w", notFound)), "wrapped errors keep their code")
+
+	cause := errors.New("connection reset")
+	internal := From(cause)
+	assert.Equal(Internal, internal.Code)
+	assert.Equal("connection reset", internal.Message)
+	assert.True(errors.Is(internal, cause))
+
+	assert.Equal(Timeout, From(context.DeadlineExceeded).Code)
+	assert.Equal(Timeout, From(status.Error(codes.DeadlineExceeded, "deadline")).Code)
+	assert.Equal(Canceled, From(context.Canceled).Code)
+	assert.Equal(499, From(status.Error(codes.Canceled, "canceled")).Status())
+}
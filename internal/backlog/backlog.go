@@ -0,0 +1,161 @@
+package backlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"strings"
+	"unicode/utf8"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	Jira   Format = "jira"
+	GitHub Format = "github"
+)
+
+const (
+	maxKeyLength         = 100
+	maxTitleLength       = 300
+	maxLinkLength        = 2000
+	maxDescriptionLength = 20000
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case CSV:
+		return CSV, nil
+	case Jira:
+		return Jira, nil
+	case GitHub:
+		return GitHub, nil
+	}
+
+	return "", errors.New("the format must be one of csv, jira or github")
+}
+
+// Detect guesses the format of a file when the client didn't tell it: anything
+// that isn't JSON is read as CSV, and Jira issues are told apart from GitHub
+// ones by their "fields" object.
+func Detect(data []byte) Format {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid(trimmed) {
+		return CSV
+	}
+	if trimmed[0] == '{' || bytes.Contains(trimmed, []byte(`"fields"`)) {
+		return Jira
+	}
+	return GitHub
+}
+
+// Mapping tells which CSV columns hold each story field. Column names are
+// matched ignoring case and surrounding spaces.
+type Mapping struct {
+	Title       string `query:"title_column"`
+	Key         string `query:"key_column"`
+	Link        string `query:"link_column"`
+	Description string `query:"description_column"`
+}
+
+func (m Mapping) withDefaults() Mapping {
+	if len(strings.TrimSpace(m.Title)) == 0 {
+		m.Title = "title"
+	}
+	if len(strings.TrimSpace(m.Key)) == 0 {
+		m.Key = "key"
+	}
+	if len(strings.TrimSpace(m.Link)) == 0 {
+		m.Link = "link"
+	}
+	if len(strings.TrimSpace(m.Description)) == 0 {
+		m.Description = "description"
+	}
+	return m
+}
+
+// Row is a story read from an imported file. Line is the 1-based position of
+// the row in the file (the CSV header counts as line 1).
+type Row struct {
+	Line        int
+	Key         string
+	Title       string
+	Link        string
+	Description string
+}
+
+// Validate trims the row and checks its fields, returning every problem found.
+func (r *Row) Validate() []stories.StoryImportError {
+	r.Key = strings.TrimSpace(r.Key)
+	r.Title = strings.TrimSpace(r.Title)
+	r.Link = strings.TrimSpace(r.Link)
+	r.Description = strings.TrimSpace(r.Description)
+
+	errs := make([]stories.StoryImportError, 0)
+	if len(r.Title) == 0 {
+		errs = append(errs, stories.StoryImportError{Row: r.Line, Field: "title", Message: "the title of the story is required"})
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"key", r.Key, maxKeyLength},
+		{"title", r.Title, maxTitleLength},
+		{"link", r.Link, maxLinkLength},
+		{"description", r.Description, maxDescriptionLength},
+	} {
+		if utf8.RuneCountInString(field.value) > field.max {
+			errs = append(errs, stories.StoryImportError{Row: r.Line, Field: field.name, Message: "the " + field.name + " of the story is too long"})
+		}
+	}
+	if len(r.Link) > 0 && !strings.HasPrefix(r.Link, "http://") && !strings.HasPrefix(r.Link, "https://") {
+		errs = append(errs, stories.StoryImportError{Row: r.Line, Field: "link", Message: "the link of the story must be an http(s) URL"})
+	}
+
+	return errs
+}
+
+// ValidateAll validates every row and splits them between the ones that can be
+// imported and the problems found. A key repeated in the same file is
+// reported on every occurrence after the first one.
+func ValidateAll(rows []Row) ([]Row, []stories.StoryImportError) {
+	valid := make([]Row, 0, len(rows))
+	errs := make([]stories.StoryImportError, 0)
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		if rowErrs := row.Validate(); len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		if len(row.Key) > 0 {
+			if line, ok := seen[row.Key]; ok {
+				errs = append(errs, stories.StoryImportError{Row: row.Line, Field: "key", Message: fmt.Sprintf("the key %q is repeated from row %d", row.Key, line)})
+				continue
+			}
+			seen[row.Key] = row.Line
+		}
+		valid = append(valid, row)
+	}
+
+	return valid, errs
+}
+
+// Parse reads the rows of a backlog file. It fails only when the file itself
+// can't be read; problems with individual rows are left to Row.Validate.
+func Parse(format Format, data []byte, mapping Mapping) ([]Row, error) {
+	switch format {
+	case CSV:
+		return parseCSV(data, mapping)
+	case Jira:
+		return parseJira(data)
+	case GitHub:
+		return parseGitHub(data)
+	}
+
+	return nil, errors.New("the format must be one of csv, jira or github")
+}
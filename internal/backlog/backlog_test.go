@@ -0,0 +1,153 @@
+package backlog
+
+import (
+	Assert "github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	assert.Equal(CSV, Detect([]byte("title,key\nStory,PKR-1\n")))
+	assert.Equal(CSV, Detect([]byte("[not json")))
+	assert.Equal(Jira, Detect([]byte(`{"issues":[]}`)))
+	assert.Equal(Jira, Detect([]byte(`[{"key":"PKR-1","fields":{"summary":"Story"}}]`)))
+	assert.Equal(GitHub, Detect([]byte(`[{"number":1,"title":"Story"}]`)))
+}
+
+func TestParseCSV(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	data := "\xef\xbb\xbfTitle, Key ,link,description\n" +
+		"First story,PKR-1,https://example.com/PKR-1,\"Multi\nline\"\n" +
+		"Second story,PKR-2\n"
+
+	rows, err := Parse(CSV, []byte(data), Mapping{})
+	assert.NoError(err)
+	assert.Equal([]Row{
+		{Line: 2, Key: "PKR-1", Title: "First story", Link: "https://example.com/PKR-1", Description: "Multi\nline"},
+		{Line: 3, Key: "PKR-2", Title: "Second story"},
+	}, rows)
+}
+
+func TestParseCSVWithMapping(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	data := "Issue key,Summary\nPKR-1,First story\n"
+
+	rows, err := Parse(CSV, []byte(data), Mapping{Title: "summary", Key: "Issue key"})
+	assert.NoError(err)
+	assert.Equal([]Row{{Line: 2, Key: "PKR-1", Title: "First story"}}, rows)
+}
+
+func TestParseCSVMissingTitleColumn(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Parse(CSV, []byte("name,key\n"), Mapping{})
+	assert.Error(err)
+	assert.Equal(`the column "title" was not found`, err.Error())
+}
+
+func TestParseCSVEmpty(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Parse(CSV, []byte(""), Mapping{})
+	assert.Error(err)
+	assert.Equal("the file is empty", err.Error())
+}
+
+func TestParseJira(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	data := `{"issues":[
+		{"key":"PKR-1","self":"https://acme.atlassian.net/rest/api/2/issue/10001","fields":{"summary":"First story","description":"Plain text"}},
+		{"key":"PKR-2","self":"https://acme.atlassian.net/rest/api/3/issue/10002","fields":{"summary":"Second story","description":
+			{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"Rich "},{"type":"text","text":"text"}]}]}}}
+	]}`
+
+	rows, err := Parse(Jira, []byte(data), Mapping{})
+	assert.NoError(err)
+	assert.Equal([]Row{
+		{Line: 1, Key: "PKR-1", Title: "First story", Link: "https://acme.atlassian.net/browse/PKR-1", Description: "Plain text"},
+		{Line: 2, Key: "PKR-2", Title: "Second story", Link: "https://acme.atlassian.net/browse/PKR-2", Description: "Rich text"},
+	}, rows)
+}
+
+func TestParseJiraInvalid(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Parse(Jira, []byte(`"foo"`), Mapping{})
+	assert.Error(err)
+	assert.Equal("the file is not a valid Jira export", err.Error())
+}
+
+func TestParseGitHub(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	data := `[
+		{"number":1,"title":"First story","body":"Body","html_url":"https://github.com/acme/app/issues/1","url":"https://api.github.com/repos/acme/app/issues/1"},
+		{"number":2,"title":"Second story","url":"https://github.com/acme/app/issues/2"}
+	]`
+
+	rows, err := Parse(GitHub, []byte(data), Mapping{})
+	assert.NoError(err)
+	assert.Equal([]Row{
+		{Line: 1, Key: "acme/app#1", Title: "First story", Link: "https://github.com/acme/app/issues/1", Description: "Body"},
+		{Line: 2, Key: "acme/app#2", Title: "Second story", Link: "https://github.com/acme/app/issues/2"},
+	}, rows)
+}
+
+func TestParseGitHubInvalid(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Parse(GitHub, []byte(`{"foo":"bar"}`), Mapping{})
+	assert.Error(err)
+	assert.Equal("the file is not a valid GitHub export", err.Error())
+}
+
+func TestValidateAll(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	valid, errs := ValidateAll([]Row{
+		{Line: 2, Key: " PKR-1 ", Title: " First story "},
+		{Line: 3, Key: "PKR-2", Title: "  "},
+		{Line: 4, Key: "PKR-1", Title: "Repeated"},
+		{Line: 5, Title: "Without key", Link: "ftp://example.com"},
+		{Line: 6, Title: "Without key"},
+	})
+
+	assert.Equal([]Row{
+		{Line: 2, Key: "PKR-1", Title: "First story"},
+		{Line: 6, Title: "Without key"},
+	}, valid)
+
+	assert.Len(errs, 3)
+	assert.Equal(3, errs[0].Row)
+	assert.Equal("title", errs[0].Field)
+	assert.Equal(4, errs[1].Row)
+	assert.Equal(`the key "PKR-1" is repeated from row 2`, errs[1].Message)
+	assert.Equal(5, errs[2].Row)
+	assert.Equal("link", errs[2].Field)
+}
+
+func TestParseFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	format, err := ParseFormat("GitHub")
+	assert.NoError(err)
+	assert.Equal(GitHub, format)
+
+	_, err = ParseFormat("xlsx")
+	assert.Error(err)
+}
@@ -0,0 +1,213 @@
+package backlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+func parseCSV(data []byte, mapping Mapping) ([]Row, error) {
+	mapping = mapping.withDefaults()
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the file: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	index := func(name string) int {
+		if i, ok := columns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return i
+		}
+		return -1
+	}
+	titleIdx := index(mapping.Title)
+	if titleIdx < 0 {
+		return nil, fmt.Errorf("the column %q was not found", mapping.Title)
+	}
+	keyIdx, linkIdx, descriptionIdx := index(mapping.Key), index(mapping.Link), index(mapping.Description)
+
+	value := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	rows := make([]Row, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the file: %v", err)
+		}
+
+		rows = append(rows, Row{
+			Line:        line,
+			Key:         value(record, keyIdx),
+			Title:       value(record, titleIdx),
+			Link:        value(record, linkIdx),
+			Description: value(record, descriptionIdx),
+		})
+	}
+
+	return rows, nil
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Self   string `json:"self"`
+	Fields struct {
+		Summary     string          `json:"summary"`
+		Description json.RawMessage `json:"description"`
+	} `json:"fields"`
+}
+
+// parseJira accepts both the result of the search API ({"issues": [...]}) and
+// a plain array of issues.
+func parseJira(data []byte) ([]Row, error) {
+	var issues []jiraIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		var result struct {
+			Issues []jiraIssue `json:"issues"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, errors.New("the file is not a valid Jira export")
+		}
+		issues = result.Issues
+	}
+
+	rows := make([]Row, len(issues))
+	for i, issue := range issues {
+		rows[i] = Row{
+			Line:        i + 1,
+			Key:         issue.Key,
+			Title:       issue.Fields.Summary,
+			Link:        jiraBrowseLink(issue.Self, issue.Key),
+			Description: jiraText(issue.Fields.Description),
+		}
+	}
+
+	return rows, nil
+}
+
+// jiraBrowseLink turns the REST address of an issue into the page people open
+// in the browser.
+func jiraBrowseLink(self, key string) string {
+	u, err := url.Parse(self)
+	if err != nil || len(u.Host) == 0 || len(key) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s/browse/%s", u.Scheme, u.Host, key)
+}
+
+// jiraText reads a description that is either plain text (API v2) or an
+// Atlassian Document (API v3), keeping only the text of the document.
+func jiraText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var node interface{}
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	var walk func(interface{})
+	walk = func(n interface{}) {
+		obj, ok := n.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if t, ok := obj["text"].(string); ok {
+			sb.WriteString(t)
+		}
+		if children, ok := obj["content"].([]interface{}); ok {
+			for _, child := range children {
+				walk(child)
+			}
+			if obj["type"] == "paragraph" {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	walk(node)
+
+	return strings.TrimSpace(sb.String())
+}
+
+type gitHubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HtmlUrl string `json:"html_url"`
+	Url     string `json:"url"`
+}
+
+// parseGitHub accepts the issues list of the REST API as well as the output
+// of `gh issue list --json number,title,body,url`.
+func parseGitHub(data []byte) ([]Row, error) {
+	var issues []gitHubIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, errors.New("the file is not a valid GitHub export")
+	}
+
+	rows := make([]Row, len(issues))
+	for i, issue := range issues {
+		link := issue.HtmlUrl
+		if len(link) == 0 && !strings.Contains(issue.Url, "://api.") {
+			link = issue.Url
+		}
+
+		rows[i] = Row{
+			Line:        i + 1,
+			Key:         gitHubKey(link, issue.Number),
+			Title:       issue.Title,
+			Link:        link,
+			Description: issue.Body,
+		}
+	}
+
+	return rows, nil
+}
+
+// gitHubKey identifies an issue as owner/repo#number, so issues of different
+// repositories don't collide in the same room.
+func gitHubKey(link string, number int) string {
+	if number == 0 {
+		return ""
+	}
+
+	u, err := url.Parse(link)
+	if err == nil {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) >= 2 && len(parts[0]) > 0 && len(parts[1]) > 0 {
+			return fmt.Sprintf("%s/%s#%d", parts[0], parts[1], number)
+		}
+	}
+
+	return fmt.Sprintf("#%d", number)
+}
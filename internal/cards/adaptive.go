@@ -0,0 +1,66 @@
+package cards
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+)
+
+const (
+	adaptiveSchema  = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveVersion = "1.4"
+)
+
+// AdaptiveCard is an Adaptive Card (https://adaptivecards.io), understood by
+// Microsoft Teams, Outlook and most bot frameworks.
+type AdaptiveCard struct {
+	Type    string    `json:"type"`
+	Schema  string    `json:"$schema"`
+	Version string    `json:"version"`
+	Body    []Element `json:"body"`
+}
+
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type Element struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Size     string `json:"size,omitempty"`
+	Weight   string `json:"weight,omitempty"`
+	IsSubtle bool   `json:"isSubtle,omitempty"`
+	Spacing  string `json:"spacing,omitempty"`
+	Wrap     bool   `json:"wrap,omitempty"`
+	Facts    []Fact `json:"facts,omitempty"`
+}
+
+// NewAdaptiveCard renders the summary of a revealed round as an Adaptive Card.
+func NewAdaptiveCard(pinCode string, summary rounds.Summary) AdaptiveCard {
+	facts := make([]Fact, len(summary.Votes))
+	for i, v := range summary.Votes {
+		facts[i] = Fact{Title: v.PlayerName, Value: vote(v)}
+	}
+
+	body := []Element{
+		{Type: "TextBlock", Text: title(summary), Size: "Medium", Weight: "Bolder", Wrap: true},
+		{Type: "TextBlock", Text: fmt.Sprintf("Room %s · round %d", pinCode, summary.Number), IsSubtle: true, Spacing: "None", Wrap: true},
+	}
+	if len(facts) > 0 {
+		body = append(body, Element{Type: "FactSet", Facts: facts})
+	}
+	if len(summary.Distribution) > 0 {
+		body = append(body, Element{Type: "TextBlock", Text: "Votes: " + distribution(summary), Wrap: true})
+	}
+	if line := confidence(summary); len(line) > 0 {
+		body = append(body, Element{Type: "TextBlock", Text: line, Wrap: true})
+	}
+	body = append(body, Element{Type: "TextBlock", Text: result(summary), Weight: "Bolder", Wrap: true})
+
+	return AdaptiveCard{
+		Type:    "AdaptiveCard",
+		Schema:  adaptiveSchema,
+		Version: adaptiveVersion,
+		Body:    body,
+	}
+}
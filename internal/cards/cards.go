@@ -0,0 +1,90 @@
+package cards
+
+import (
+	"errors"
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	Adaptive Format = "adaptive"
+	Markdown Format = "markdown"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case Adaptive:
+		return Adaptive, nil
+	case Markdown, "md":
+		return Markdown, nil
+	}
+
+	return "", errors.New("the format must be one of adaptive or markdown")
+}
+
+// title is what the card shows as heading: the story, or the round number
+// when the round isn't about a story.
+func title(summary rounds.Summary) string {
+	if len(summary.StoryTitle) > 0 {
+		return summary.StoryTitle
+	}
+	return fmt.Sprintf("Round %d", summary.Number)
+}
+
+// result is the one line conclusion of the round.
+func result(summary rounds.Summary) string {
+	parts := make([]string, 0, 2)
+	if summary.Average != nil {
+		parts = append(parts, "Average: "+strconv.FormatFloat(*summary.Average, 'f', -1, 64))
+	}
+	if summary.Consensus {
+		parts = append(parts, "Consensus")
+	}
+	if len(parts) == 0 {
+		return "No numeric votes"
+	}
+	return strings.Join(parts, " · ")
+}
+
+func distribution(summary rounds.Summary) string {
+	values := make([]string, 0, len(summary.Distribution))
+	for value := range summary.Distribution {
+		values = append(values, value)
+	}
+	rounds.SortCards(values)
+
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%s × %d", value, summary.Distribution[value])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// confidence is the line about how confident the players are in their votes,
+// empty unless the room asks for it and somebody voted.
+func confidence(summary rounds.Summary) string {
+	if summary.Confidence == nil || summary.Confidence.Average == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, rounds.MaxConfidence)
+	for level := rounds.MinConfidence; level <= rounds.MaxConfidence; level++ {
+		if count := summary.Confidence.Distribution[strconv.Itoa(level)]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d × %d", level, count))
+		}
+	}
+	return fmt.Sprintf("Confidence: %s · Average: %s · Lowest: %d", strings.Join(parts, ", "),
+		strconv.FormatFloat(*summary.Confidence.Average, 'f', -1, 64), summary.Confidence.Lowest)
+}
+
+// vote is the vote of a player, with their confidence when they gave it.
+func vote(v rounds.Vote) string {
+	if v.Confidence == 0 {
+		return v.Value
+	}
+	return fmt.Sprintf("%s (confidence %d)", v.Value, v.Confidence)
+}
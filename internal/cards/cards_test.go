@@ -0,0 +1,157 @@
+package cards
+
+import (
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"testing"
+)
+
+var average = 5.33
+
+var summary = rounds.Summary{
+	Number:     2,
+	StoryTitle: "Login *page*",
+	Votes: []rounds.Vote{
+		{PlayerId: "1", PlayerName: "Ana", Value: "3"},
+		{PlayerId: "2", PlayerName: "Bob", Value: "5"},
+		{PlayerId: "3", PlayerName: "Carl", Value: "8"},
+	},
+	Distribution: map[string]int{"8": 1, "3": 1, "5": 1},
+	Average:      &average,
+}
+
+func TestParseFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	format, err := ParseFormat("Adaptive")
+	assert.NoError(err)
+	assert.Equal(Adaptive, format)
+
+	format, err = ParseFormat("md")
+	assert.NoError(err)
+	assert.Equal(Markdown, format)
+
+	_, err = ParseFormat("html")
+	assert.EqualError(err, "the format must be one of adaptive or markdown")
+}
+
+func TestNewAdaptiveCard(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	card := NewAdaptiveCard("123456", summary)
+
+	data, err := json.Marshal(card)
+	assert.NoError(err)
+
+	decoded := make(map[string]interface{})
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.Equal("AdaptiveCard", decoded["type"])
+	assert.Equal("http://adaptivecards.io/schemas/adaptive-card.json", decoded["$schema"])
+	assert.Equal("1.4", decoded["version"])
+
+	assert.Len(card.Body, 5)
+	assert.Equal("Login *page*", card.Body[0].Text)
+	assert.Equal("Room 123456 · round 2", card.Body[1].Text)
+	assert.Equal([]Fact{{"Ana", "3"}, {"Bob", "5"}, {"Carl", "8"}}, card.Body[2].Facts)
+	assert.Equal("Votes: 3 × 1, 5 × 1, 8 × 1", card.Body[3].Text)
+	assert.Equal("Average: 5.33", card.Body[4].Text)
+}
+
+func TestNewAdaptiveCardWithoutVotes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	card := NewAdaptiveCard("123456", rounds.Summary{Number: 1})
+
+	assert.Len(card.Body, 3)
+	assert.Equal("Round 1", card.Body[0].Text)
+	assert.Equal("No numeric votes", card.Body[2].Text)
+}
+
+func TestNewMarkdown(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	assert.Equal("**Login \\*page\\***\n"+
+		"Room 123456 · round 2\n\n"+
+		"| Player | Vote |\n|---|---|\n"+
+		"| Ana | 3 |\n| Bob | 5 |\n| Carl | 8 |\n\n"+
+		"Votes: 3 × 1, 5 × 1, 8 × 1\n"+
+		"**Average: 5.33**\n", NewMarkdown("123456", summary))
+}
+
+func TestNewMarkdownAnonymous(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	eight := 8.0
+	anonymous := rounds.Summary{
+		Number:       1,
+		Anonymous:    true,
+		Votes:        []rounds.Vote{},
+		Cards:        []string{"8", "8"},
+		Distribution: map[string]int{"8": 2},
+		Average:      &eight,
+		Consensus:    true,
+	}
+
+	markdown := NewMarkdown("123456", anonymous)
+	assert.NotContains(markdown, "| Player |")
+	assert.Contains(markdown, "Votes: 8 × 2\n")
+	assert.Len(NewAdaptiveCard("123456", anonymous).Body, 4)
+}
+
+func TestConfidence(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	three := 3.0
+	five := 5.0
+	confident := rounds.Summary{
+		Number: 1,
+		Votes: []rounds.Vote{
+			{PlayerName: "Ana", Value: "5", Confidence: 2},
+			{PlayerName: "Bob", Value: "5", Confidence: 4},
+		},
+		Distribution: map[string]int{"5": 2},
+		Average:      &five,
+		Consensus:    true,
+		Confidence: &rounds.Confidence{
+			Distribution: map[string]int{"2": 1, "4": 1},
+			Average:      &three,
+			Lowest:       2,
+		},
+	}
+
+	markdown := NewMarkdown("123456", confident)
+	assert.Contains(markdown, "| Ana | 5 (confidence 2) |\n| Bob | 5 (confidence 4) |\n")
+	assert.Contains(markdown, "Confidence: 2 × 1, 4 × 1 · Average: 3 · Lowest: 2\n")
+
+	card := NewAdaptiveCard("123456", confident)
+	assert.Len(card.Body, 6)
+	assert.Equal([]Fact{{"Ana", "5 (confidence 2)"}, {"Bob", "5 (confidence 4)"}}, card.Body[2].Facts)
+	assert.Equal("Confidence: 2 × 1, 4 × 1 · Average: 3 · Lowest: 2", card.Body[4].Text)
+
+	// Nobody voted yet
+	confident.Confidence = &rounds.Confidence{Distribution: map[string]int{}}
+	assert.NotContains(NewMarkdown("123456", confident), "Confidence:")
+}
+
+func TestNewMarkdownConsensus(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	five := 5.0
+	markdown := NewMarkdown("123456", rounds.Summary{
+		Number:       1,
+		Votes:        []rounds.Vote{{PlayerName: "Ana", Value: "5"}},
+		Distribution: map[string]int{"5": 1},
+		Average:      &five,
+		Consensus:    true,
+	})
+
+	assert.Contains(markdown, "**Average: 5 · Consensus**")
+}
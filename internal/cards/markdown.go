@@ -0,0 +1,41 @@
+package cards
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"strings"
+)
+
+var markdownReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "|", `\|`, "\r\n", " ", "\n", " ")
+
+func escape(text string) string {
+	return markdownReplacer.Replace(text)
+}
+
+// NewMarkdown renders the summary of a revealed round as Markdown that still
+// reads well as plain text, for chats without rich cards.
+func NewMarkdown(pinCode string, summary rounds.Summary) string {
+	sb := new(strings.Builder)
+
+	fmt.Fprintf(sb, "**%s**\n", escape(title(summary)))
+	fmt.Fprintf(sb, "Room %s · round %d\n\n", pinCode, summary.Number)
+
+	if len(summary.Votes) > 0 {
+		sb.WriteString("| Player | Vote |\n|---|---|\n")
+		for _, v := range summary.Votes {
+			fmt.Fprintf(sb, "| %s | %s |\n", escape(v.PlayerName), escape(vote(v)))
+		}
+		sb.WriteString("\n")
+	}
+	if len(summary.Distribution) > 0 {
+		// The only votes of an anonymous round
+		fmt.Fprintf(sb, "Votes: %s\n", escape(distribution(summary)))
+	}
+	if line := confidence(summary); len(line) > 0 {
+		fmt.Fprintf(sb, "%s\n", line)
+	}
+
+	fmt.Fprintf(sb, "**%s**\n", result(summary))
+
+	return sb.String()
+}
@@ -0,0 +1,357 @@
+package config
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/logging"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "<redacted>"
+
+// Tracing exporters
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+// Storage backends
+const (
+	StorageFirestore = "firestore"
+)
+
+type Firestore struct {
+	ProjectId    string `yaml:"project_id"`
+	EmulatorHost string `yaml:"emulator_host"`
+}
+
+type Cors struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+// RateLimit limits the requests per client IP. A Max of 0 disables it.
+type RateLimit struct {
+	Max    int           `yaml:"max"`
+	Window time.Duration `yaml:"window"`
+}
+
+type Timeouts struct {
+	Read  time.Duration `yaml:"read"`
+	Write time.Duration `yaml:"write"`
+	Idle  time.Duration `yaml:"idle"`
+	// How long to wait for the requests in flight and the background jobs
+	// when stopping
+	Shutdown time.Duration `yaml:"shutdown"`
+	// Deadline of the storage calls made while answering a request
+	Request time.Duration `yaml:"request"`
+}
+
+type Webhooks struct {
+	Urls   []string `yaml:"urls"`
+	Secret string   `yaml:"secret"`
+	// Whether the rooms may register webhooks to private addresses, e.g. for
+	// local development
+	AllowPrivate bool `yaml:"allow_private"`
+}
+
+type Tracing struct {
+	// none, stdout or otlp
+	Exporter string `yaml:"exporter"`
+	// Base URL of the OTLP/HTTP collector, e.g. http://localhost:4318
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"service_name"`
+}
+
+type Log struct {
+	// debug, info, warn or error
+	Level string `yaml:"level"`
+}
+
+type Slack struct {
+	SigningSecret string `yaml:"signing_secret"`
+}
+
+// LegacyRoutes are the routes without a version, from before /v1. They are
+// answered like /v1, with headers saying they are deprecated.
+type LegacyRoutes struct {
+	Enabled bool `yaml:"enabled"`
+	// When the routes will be removed, e.g. 2027-04-01. Not announced when
+	// not set.
+	Sunset time.Time `yaml:"sunset"`
+}
+
+// Config is the configuration of the service. It is read from an optional
+// YAML or JSON file and then from the environment, which has precedence.
+type Config struct {
+	Port         string       `yaml:"port"`
+	Storage      string       `yaml:"storage"`
+	Firestore    Firestore    `yaml:"firestore"`
+	Cors         Cors         `yaml:"cors"`
+	RateLimit    RateLimit    `yaml:"rate_limit"`
+	PinLength    int          `yaml:"pin_length"`
+	Timeouts     Timeouts     `yaml:"timeouts"`
+	Webhooks     Webhooks     `yaml:"webhooks"`
+	Slack        Slack        `yaml:"slack"`
+	Log          Log          `yaml:"log"`
+	Tracing      Tracing      `yaml:"tracing"`
+	LegacyRoutes LegacyRoutes `yaml:"legacy_routes"`
+}
+
+// Default is the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Port:    "8080",
+		Storage: StorageFirestore,
+		Cors: Cors{
+			AllowOrigins: []string{"*"},
+		},
+		RateLimit: RateLimit{
+			Window: time.Minute,
+		},
+		PinLength: 6,
+		Timeouts: Timeouts{
+			Read:     30 * time.Second,
+			Write:    30 * time.Second,
+			Idle:     2 * time.Minute,
+			Shutdown: 10 * time.Second,
+			Request:  10 * time.Second,
+		},
+		Webhooks: Webhooks{
+			Urls: []string{},
+		},
+		Log: Log{
+			Level: "info",
+		},
+		Tracing: Tracing{
+			Exporter:    TracingNone,
+			ServiceName: "scrumpoker-api",
+		},
+		LegacyRoutes: LegacyRoutes{
+			Enabled: true,
+		},
+	}
+}
+
+// Load reads the configuration: the defaults, then the file at path (when
+// not empty), then the environment. The result is validated.
+func Load(path string, env func(string) (string, bool)) (*Config, error) {
+	conf := Default()
+
+	if len(path) > 0 {
+		if err := conf.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conf.readEnv(env); err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// readFile reads a YAML file. JSON files are read the same way, as JSON is
+// valid YAML.
+func (conf *Config) readFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, conf); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (conf *Config) readEnv(env func(string) (string, bool)) error {
+	str := func(name string, target *string) {
+		if value, ok := env(name); ok {
+			*target = value
+		}
+	}
+	list := func(name string, target *[]string) {
+		if value, ok := env(name); ok {
+			*target = splitList(value)
+		}
+	}
+
+	var errs Errors
+	integer := func(name string, target *int) {
+		if value, ok := env(name); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s must be an integer", name))
+				return
+			}
+			*target = n
+		}
+	}
+	boolean := func(name string, target *bool) {
+		if value, ok := env(name); ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s must be true or false", name))
+				return
+			}
+			*target = b
+		}
+	}
+	date := func(name string, target *time.Time) {
+		if value, ok := env(name); ok {
+			d, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s must be a date like 2027-04-01", name))
+				return
+			}
+			*target = d
+		}
+	}
+	duration := func(name string, target *time.Duration) {
+		if value, ok := env(name); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s must be a duration like 30s or 1m", name))
+				return
+			}
+			*target = d
+		}
+	}
+
+	str("PORT", &conf.Port)
+	str("STORAGE_BACKEND", &conf.Storage)
+	str("GOOGLE_CLOUD_PROJECT", &conf.Firestore.ProjectId)
+	str("FIRESTORE_EMULATOR_HOST", &conf.Firestore.EmulatorHost)
+	list("CORS_ALLOW_ORIGINS", &conf.Cors.AllowOrigins)
+	integer("RATE_LIMIT_MAX", &conf.RateLimit.Max)
+	duration("RATE_LIMIT_WINDOW", &conf.RateLimit.Window)
+	integer("PIN_LENGTH", &conf.PinLength)
+	duration("READ_TIMEOUT", &conf.Timeouts.Read)
+	duration("WRITE_TIMEOUT", &conf.Timeouts.Write)
+	duration("IDLE_TIMEOUT", &conf.Timeouts.Idle)
+	duration("SHUTDOWN_TIMEOUT", &conf.Timeouts.Shutdown)
+	duration("REQUEST_TIMEOUT", &conf.Timeouts.Request)
+	list("WEBHOOK_URLS", &conf.Webhooks.Urls)
+	str("WEBHOOK_SECRET", &conf.Webhooks.Secret)
+	boolean("WEBHOOK_ALLOW_PRIVATE", &conf.Webhooks.AllowPrivate)
+	str("SLACK_SIGNING_SECRET", &conf.Slack.SigningSecret)
+	str("LOG_LEVEL", &conf.Log.Level)
+	str("TRACING_EXPORTER", &conf.Tracing.Exporter)
+	str("OTEL_EXPORTER_OTLP_ENDPOINT", &conf.Tracing.Endpoint)
+	str("OTEL_SERVICE_NAME", &conf.Tracing.ServiceName)
+	boolean("LEGACY_ROUTES", &conf.LegacyRoutes.Enabled)
+	date("LEGACY_ROUTES_SUNSET", &conf.LegacyRoutes.Sunset)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Errors lists everything that is wrong with a configuration, so all of it
+// can be fixed at once.
+type Errors []string
+
+func (errs Errors) Error() string {
+	return "invalid configuration: " + strings.Join(errs, "; ")
+}
+
+func (conf *Config) Validate() error {
+	var errs Errors
+
+	if port, err := strconv.Atoi(conf.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, "port must be a number between 1 and 65535")
+	}
+	if conf.Storage != StorageFirestore {
+		errs = append(errs, fmt.Sprintf("storage must be %s", StorageFirestore))
+	}
+	if len(conf.Cors.AllowOrigins) == 0 {
+		errs = append(errs, "cors.allow_origins must not be empty")
+	}
+	if conf.RateLimit.Max < 0 {
+		errs = append(errs, "rate_limit.max must not be negative")
+	}
+	if conf.RateLimit.Max > 0 && conf.RateLimit.Window <= 0 {
+		errs = append(errs, "rate_limit.window must be positive")
+	}
+	if conf.PinLength < 4 || conf.PinLength > 9 {
+		errs = append(errs, "pin_length must be between 4 and 9")
+	}
+	if conf.Timeouts.Read < 0 || conf.Timeouts.Write < 0 || conf.Timeouts.Idle < 0 {
+		errs = append(errs, "timeouts must not be negative")
+	}
+	if conf.Timeouts.Shutdown <= 0 {
+		errs = append(errs, "timeouts.shutdown must be positive")
+	}
+	if conf.Timeouts.Request <= 0 {
+		errs = append(errs, "timeouts.request must be positive")
+	}
+	if _, err := logging.ParseLevel(conf.Log.Level); err != nil {
+		errs = append(errs, "log.level: "+err.Error())
+	}
+	switch conf.Tracing.Exporter {
+	case TracingNone, TracingStdout:
+	case TracingOTLP:
+		if !isHttpUrl(conf.Tracing.Endpoint) {
+			errs = append(errs, fmt.Sprintf("tracing.endpoint: %q is not an http(s) URL", conf.Tracing.Endpoint))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("tracing.exporter must be one of %s, %s or %s", TracingNone, TracingStdout, TracingOTLP))
+	}
+	for _, webhook := range conf.Webhooks.Urls {
+		if !isHttpUrl(webhook) {
+			errs = append(errs, fmt.Sprintf("webhooks.urls: %q is not an http(s) URL", webhook))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func isHttpUrl(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// Redacted is a copy of the configuration that is safe to print: secrets
+// that are set are replaced.
+func (conf Config) Redacted() Config {
+	if len(conf.Webhooks.Secret) > 0 {
+		conf.Webhooks.Secret = redacted
+	}
+	if len(conf.Slack.SigningSecret) > 0 {
+		conf.Slack.SigningSecret = redacted
+	}
+	return conf
+}
+
+// String is the configuration as YAML, with secrets redacted.
+func (conf Config) String() string {
+	data, err := yaml.Marshal(conf.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
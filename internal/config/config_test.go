@@ -0,0 +1,196 @@
+package config
+
+import (
+	Assert "github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf, err := Load("", env(nil))
+
+	assert.NoError(err)
+	assert.Equal(Default(), conf)
+}
+
+func TestLoadFromEnv(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf, err := Load("", env(map[string]string{
+		"PORT":                        "3000",
+		"GOOGLE_CLOUD_PROJECT":        "scrumpoker",
+		"CORS_ALLOW_ORIGINS":          "https://a.example, https://b.example",
+		"RATE_LIMIT_MAX":              "60",
+		"RATE_LIMIT_WINDOW":           "30s",
+		"PIN_LENGTH":                  "8",
+		"WEBHOOK_URLS":                "https://hooks.example/1,",
+		"WEBHOOK_ALLOW_PRIVATE":       "true",
+		"SLACK_SIGNING_SECRET":        "s3cr3t",
+		"LOG_LEVEL":                   "debug",
+		"TRACING_EXPORTER":            "otlp",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318",
+		"LEGACY_ROUTES":               "false",
+		"LEGACY_ROUTES_SUNSET":        "2027-04-01",
+	}))
+
+	assert.NoError(err)
+	assert.Equal("3000", conf.Port)
+	assert.Equal("scrumpoker", conf.Firestore.ProjectId)
+	assert.Equal([]string{"https://a.example", "https://b.example"}, conf.Cors.AllowOrigins)
+	assert.Equal(RateLimit{Max: 60, Window: 30 * time.Second}, conf.RateLimit)
+	assert.Equal(8, conf.PinLength)
+	assert.Equal([]string{"https://hooks.example/1"}, conf.Webhooks.Urls)
+	assert.True(conf.Webhooks.AllowPrivate)
+	assert.Equal("s3cr3t", conf.Slack.SigningSecret)
+	assert.Equal("debug", conf.Log.Level)
+	assert.Equal(Tracing{Exporter: TracingOTLP, Endpoint: "http://localhost:4318", ServiceName: "scrumpoker-api"}, conf.Tracing)
+	assert.Equal(LegacyRoutes{Enabled: false, Sunset: time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)}, conf.LegacyRoutes)
+}
+
+func TestLoadFromYAMLFile(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	path := writeFile(t, "config.yaml", `
+port: "9000"
+firestore:
+  project_id: from-file
+pin_length: 5
+timeouts:
+  read: 5s
+legacy_routes:
+  sunset: 2027-04-01
+`)
+
+	conf, err := Load(path, env(map[string]string{
+		"PORT": "9090",
+	}))
+
+	assert.NoError(err)
+	assert.Equal("9090", conf.Port, "the environment has precedence over the file")
+	assert.Equal("from-file", conf.Firestore.ProjectId)
+	assert.Equal(5, conf.PinLength)
+	assert.Equal(5*time.Second, conf.Timeouts.Read)
+	assert.Equal(30*time.Second, conf.Timeouts.Write, "what the file doesn't set keeps the default")
+	assert.True(conf.LegacyRoutes.Enabled)
+	assert.Equal(time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC), conf.LegacyRoutes.Sunset)
+}
+
+func TestLoadFromJSONFile(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	path := writeFile(t, "config.json", `{"cors": {"allow_origins": ["https://a.example"]}, "rate_limit": {"max": 10}}`)
+
+	conf, err := Load(path, env(nil))
+
+	assert.NoError(err)
+	assert.Equal([]string{"https://a.example"}, conf.Cors.AllowOrigins)
+	assert.Equal(RateLimit{Max: 10, Window: time.Minute}, conf.RateLimit)
+}
+
+func TestLoadFileNotFound(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Load(filepath.Join(os.TempDir(), "does-not-exist.yaml"), env(nil))
+
+	assert.Error(err)
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Load("", env(map[string]string{
+		"PIN_LENGTH":           "six",
+		"READ_TIMEOUT":         "10",
+		"LEGACY_ROUTES":        "sometimes",
+		"LEGACY_ROUTES_SUNSET": "April",
+	}))
+
+	assert.EqualError(err, "invalid configuration: PIN_LENGTH must be an integer; READ_TIMEOUT must be a duration like 30s or 1m; "+
+		"LEGACY_ROUTES must be true or false; LEGACY_ROUTES_SUNSET must be a date like 2027-04-01")
+}
+
+func TestValidate(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf := Default()
+	conf.Port = "http"
+	conf.Storage = "redis"
+	conf.Cors.AllowOrigins = []string{}
+	conf.RateLimit = RateLimit{Max: 10}
+	conf.PinLength = 12
+	conf.Timeouts.Idle = -time.Second
+	conf.Timeouts.Shutdown = 0
+	conf.Timeouts.Request = 0
+	conf.Webhooks.Urls = []string{"ftp://hooks.example"}
+	conf.Log.Level = "verbose"
+	conf.Tracing.Exporter = TracingOTLP
+
+	err := conf.Validate()
+
+	assert.IsType(Errors{}, err)
+	assert.Equal(Errors{
+		"port must be a number between 1 and 65535",
+		"storage must be firestore",
+		"cors.allow_origins must not be empty",
+		"rate_limit.window must be positive",
+		"pin_length must be between 4 and 9",
+		"timeouts must not be negative",
+		"timeouts.shutdown must be positive",
+		"timeouts.request must be positive",
+		"log.level: the log level must be one of debug, info, warn, error",
+		`tracing.endpoint: "" is not an http(s) URL`,
+		`webhooks.urls: "ftp://hooks.example" is not an http(s) URL`,
+	}, err)
+}
+
+func TestString(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf := Default()
+	conf.Webhooks.Secret = "webhook-secret"
+	conf.Slack.SigningSecret = "slack-secret"
+
+	str := conf.String()
+
+	assert.NotContains(str, "webhook-secret")
+	assert.NotContains(str, "slack-secret")
+	assert.Contains(str, "signing_secret: <redacted>")
+	assert.Contains(str, "read: 30s")
+	assert.Equal("slack-secret", conf.Slack.SigningSecret, "the configuration itself is not changed")
+}
@@ -0,0 +1,131 @@
+package health
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/health"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"google.golang.org/api/iterator"
+	"sync"
+	"time"
+)
+
+// How long a single dependency may take to answer
+const checkTimeout = 2 * time.Second
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// readinessChecks are the dependencies the service needs to serve requests.
+func readinessChecks() []check {
+	return []check{
+		{"firestore", func(ctx context.Context) error {
+			db := new(firestore.Client)
+			container.Make(&db)
+
+			_, err := db.Collection("rooms").Limit(1).Documents(ctx).Next()
+			if err == iterator.Done {
+				return nil
+			}
+			return err
+		}},
+		{"config", func(ctx context.Context) error {
+			conf := new(config.Config)
+			container.Make(&conf)
+
+			return conf.Validate()
+		}},
+		{"webhooks", func(ctx context.Context) error {
+			dispatcher := new(webhooks.Dispatcher)
+			container.Make(&dispatcher)
+
+			if !dispatcher.Running() {
+				return errors.New("the webhook dispatcher is stopped")
+			}
+			return nil
+		}},
+	}
+}
+
+// runChecks runs the checks concurrently, each one with its own timeout.
+func runChecks(ctx context.Context, checks []check) health.Response {
+	response := health.Response{
+		Status: health.StatusOk,
+		Checks: make([]health.Check, len(checks)),
+	}
+
+	wg := new(sync.WaitGroup)
+	for i, ck := range checks {
+		wg.Add(1)
+		go func(i int, ck check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := ck.run(checkCtx)
+
+			response.Checks[i] = health.Check{
+				Name:      ck.name,
+				Status:    health.StatusOk,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				response.Checks[i].Status = health.StatusUnavailable
+				response.Checks[i].Error = err.Error()
+			}
+		}(i, ck)
+	}
+	wg.Wait()
+
+	for _, ck := range response.Checks {
+		if ck.Status != health.StatusOk {
+			response.Status = health.StatusUnavailable
+		}
+	}
+
+	return response
+}
+
+// @Summary Liveness probe
+// @Description Answers as long as the process is able to serve requests. It doesn't check any dependency.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} health.Response
+// @Router /healthz [get]
+func liveness(c *fiber.Ctx) error {
+	return c.JSON(health.Response{
+		Status: health.StatusOk,
+		Checks: []health.Check{},
+	})
+}
+
+// @Summary Readiness probe
+// @Description Checks that the Firestore is reachable, the configuration is valid and the background workers are running.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} health.Response
+// @Failure 503 {object} health.Response
+// @Router /readyz [get]
+func readiness(c *fiber.Ctx) error {
+	response := runChecks(context.Background(), readinessChecks())
+
+	if response.Status != health.StatusOk {
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+	return c.JSON(response)
+}
+
+// Registrar endpoints
+func Register(router fiber.Router) {
+
+	router.Get("/healthz", liveness)
+	router.Get("/readyz", readiness)
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/health"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"golang.org/x/net/nettest"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var app *fiber.App
+var db *firestore.Client
+
+func TestMain(m *testing.M) {
+
+	_ = di.SetupDependencies(config.Default())
+	container.Make(&db)
+
+	app = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	Register(app)
+
+	listener, _ := nettest.NewLocalListener("tcp")
+	go func() {
+		_ = app.Listener(listener)
+	}()
+
+	m.Run()
+
+	defer func() {
+		db.Close()
+		_ = app.Shutdown()
+	}()
+}
+
+func TestRegisterRoutes(t *testing.T) {
+
+	router := new(test.MockRouter)
+	router.On("Get", mock.Anything, mock.Anything).Return(router)
+
+	Register(router)
+
+	router.AssertCalled(t, "Get", "/healthz", mock.Anything)
+	router.AssertCalled(t, "Get", "/readyz", mock.Anything)
+}
+
+func TestLiveness(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.JSONEq(`{"status":"ok","checks":[]}`, string(bodyResp))
+}
+
+func TestReadiness(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	response := new(health.Response)
+	assert.NoError(json.Unmarshal(bodyResp, response))
+	assert.Equal(health.StatusOk, response.Status)
+
+	names := make([]string, 0)
+	for _, ck := range response.Checks {
+		names = append(names, ck.Name)
+		assert.Equal(health.StatusOk, ck.Status, ck.Error)
+	}
+	assert.Equal([]string{"firestore", "config", "webhooks"}, names)
+}
+
+func TestRunChecks(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	response := runChecks(context.Background(), []check{
+		{"ok", func(ctx context.Context) error {
+			return nil
+		}},
+		{"failing", func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}},
+	})
+
+	assert.Equal(health.StatusUnavailable, response.Status)
+	assert.Equal("ok", response.Checks[0].Name)
+	assert.Equal(health.StatusOk, response.Checks[0].Status)
+	assert.Empty(response.Checks[0].Error)
+	assert.Equal("failing", response.Checks[1].Name)
+	assert.Equal(health.StatusUnavailable, response.Checks[1].Status)
+	assert.Equal("connection refused", response.Checks[1].Error)
+}
+
+func TestRunChecksTimeout(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	start := time.Now()
+	response := runChecks(context.Background(), []check{
+		{"hanging", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	})
+
+	assert.Less(int64(time.Since(start)), int64(checkTimeout+time.Second))
+	assert.Equal(health.StatusUnavailable, response.Status)
+	assert.Equal(context.DeadlineExceeded.Error(), response.Checks[0].Error)
+	assert.GreaterOrEqual(response.Checks[0].LatencyMs, float64(checkTimeout.Milliseconds()))
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// handler serves the default registry of the metrics
+var handler = fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler())
+
+// @Summary Prometheus metrics
+// @Description Metrics of the service in the Prometheus text format: HTTP requests by route, rooms, joins, votes, reveals, storage calls and rate limit rejections, plus those of the Go runtime.
+// @Tags Metrics
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func getMetrics(c *fiber.Ctx) error {
+	handler(c.Context())
+	return nil
+}
+
+// Registrar endpoints
+func Register(router fiber.Router) {
+
+	router.Get("/metrics", getMetrics)
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/common/expfmt"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestRegisterRoutes(t *testing.T) {
+
+	router := new(test.MockRouter)
+	router.On("Get", mock.Anything, mock.Anything).Return(router)
+
+	Register(router)
+
+	router.AssertCalled(t, "Get", "/metrics", mock.Anything)
+}
+
+func TestGetMetrics(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	Register(app)
+
+	metrics.RoomsCreated.Inc()
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	res, err := app.Test(req)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Equal(string(expfmt.FmtText), res.Header.Get("Content-Type"))
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.Contains(string(bodyResp), "# TYPE scrumpoker_rooms_created_total counter\nscrumpoker_rooms_created_total 1\n")
+	assert.Contains(string(bodyResp), "# TYPE scrumpoker_discussion_duration_seconds histogram\n")
+}
@@ -0,0 +1,59 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/swaggo/swag"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/openapi"
+	"sync"
+)
+
+var (
+	once     sync.Once
+	document []byte
+	buildErr error
+)
+
+// Document builds the OpenAPI 3.1 document from the Swagger 2.0 one swag
+// generates, once.
+func Document() ([]byte, error) {
+	once.Do(func() {
+		swagger, err := swag.ReadDoc()
+		if err != nil {
+			buildErr = err
+			return
+		}
+
+		doc, err := openapi.Convert([]byte(swagger))
+		if err != nil {
+			buildErr = err
+			return
+		}
+
+		document, buildErr = json.Marshal(doc)
+	})
+	return document, buildErr
+}
+
+// @Summary OpenAPI document
+// @Description The OpenAPI 3.1 document of the API, with the security schemes, the codes of the errors and the events of the rooms.
+// @Tags OpenAPI
+// @Produce json
+// @Success 200 {object} object
+// @Failure 500 {object} models.Error
+// @Router /openapi.json [get]
+func getDocument(c *fiber.Ctx) error {
+	doc, err := Document()
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(doc)
+}
+
+// Registrar endpoints
+func Register(router fiber.Router) {
+
+	router.Get("/openapi.json", getDocument)
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	_ "github.com/thiagopereiramartinez/scrumpoker-run.api/api"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"net/http"
+	"testing"
+)
+
+func TestRegisterRoutes(t *testing.T) {
+
+	router := new(test.MockRouter)
+	router.On("Get", mock.Anything, mock.Anything).Return(router)
+
+	Register(router)
+
+	router.AssertCalled(t, "Get", "/openapi.json", mock.Anything)
+}
+
+func TestGetDocument(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	Register(app)
+
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	res, err := app.Test(req)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Equal(fiber.MIMEApplicationJSON, res.Header.Get("Content-Type"))
+
+	doc := new(struct {
+		OpenApi    string                 `json:"openapi"`
+		Paths      map[string]interface{} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+		} `json:"components"`
+	})
+	assert.NoError(json.NewDecoder(res.Body).Decode(doc))
+	assert.Equal("3.1.0", doc.OpenApi)
+	assert.Contains(doc.Paths, "/v1/rooms/{pincode}/rounds/{n}/votes")
+	assert.Contains(doc.Components.SecuritySchemes, "PlayerToken")
+}
@@ -0,0 +1,70 @@
+package rooms
+
+import (
+	"cloud.google.com/go/firestore"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/cards"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+)
+
+// @Summary Get the result card of a round
+// @Description Renders a revealed round as an Adaptive Card (Microsoft Teams, bots) or as Markdown, ready to be posted to a chat.
+// @Tags Rooms
+// @Param pincode path string true "Pin Code of the Room"
+// @Param n path int true "Number of the Round"
+// @Param format query string false "Card format" Enums(adaptive, markdown) default(adaptive)
+// @Produce json
+// @Produce text/markdown
+// @Success 200 {object} cards.AdaptiveCard
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/rounds/{n}/card [get]
+func getRoundCard(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	format, err := cards.ParseFormat(c.Query("format", string(cards.Adaptive)))
+	if err != nil {
+		return apierror.Invalid("format", err.Error())
+	}
+
+	number, err := roundNumber(c)
+	if err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+
+	utils.SetRoom(c, room.Id)
+
+	round, err := GetRound(ctx, db, room.Id, number)
+	if err != nil {
+		return err
+	}
+
+	if !round.Revealed {
+		return apierror.New(apierror.Conflict, "the round was not revealed yet")
+	}
+
+	summary, err := Summarize(ctx, db, room.Id, *round)
+	if err != nil {
+		return err
+	}
+
+	if format == cards.Markdown {
+		c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+		return c.SendString(cards.NewMarkdown(room.PinCode, *summary))
+	}
+
+	return c.JSON(cards.NewAdaptiveCard(room.PinCode, *summary))
+}
@@ -0,0 +1,121 @@
+package rooms
+
+import (
+	"encoding/json"
+	"fmt"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/cards"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestGetRoundCardAdaptive(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	round, err := StartRound(ctx, db, room, "")
+	assert.NoError(err)
+	_, err = Reveal(ctx, db, room, round.Number)
+	assert.NoError(err)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/rounds/%d/card", room.PinCode, round.Number), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	card := new(cards.AdaptiveCard)
+	assert.NoError(json.Unmarshal(bodyResp, card))
+	assert.Equal("AdaptiveCard", card.Type)
+	assert.Equal("Round 1", card.Body[0].Text)
+}
+
+func TestGetRoundCardMarkdown(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	round, err := StartRound(ctx, db, room, "")
+	assert.NoError(err)
+	_, err = Reveal(ctx, db, room, round.Number)
+	assert.NoError(err)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/rounds/%d/card?format=markdown", room.PinCode, round.Number), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Contains(res.Header.Get("Content-Type"), "text/markdown")
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.Contains(string(bodyResp), fmt.Sprintf("Room %s · round 1", room.PinCode))
+}
+
+func TestGetRoundCardNotRevealed(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	round, err := StartRound(ctx, db, room, "")
+	assert.NoError(err)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/rounds/%d/card", room.PinCode, round.Number), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(409, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      409,
+		ErrorCode: "conflict",
+		Message:   "the round was not revealed yet",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestGetRoundCardThatRoundNotExists(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/rounds/42/card", room.PinCode), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      404,
+		ErrorCode: "not_found",
+		Message:   "round not found",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestGetRoundCardInvalidFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	req, _ := http.NewRequest("GET", "/rooms/123456/rounds/1/card?format=html", nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the format must be one of adaptive or markdown",
+		Fields: []models.FieldError{
+			{Field: "format", Message: "the format must be one of adaptive or markdown"},
+		},
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
@@ -0,0 +1,196 @@
+package rooms
+
+import (
+	"bufio"
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"io"
+	"time"
+)
+
+// KeepAlive is how often an idle stream of events sends a comment, so the
+// proxies keep it open and a gone client is noticed.
+var KeepAlive = 15 * time.Second
+
+// Reconnect is how long the clients are told to wait before reconnecting when
+// the server shuts down, so they reach the instance that replaces it.
+var Reconnect = 5 * time.Second
+
+// Disconnect is how long a player who stops following the events of a room
+// is given to be marked offline.
+var Disconnect = 10 * time.Second
+
+// Publish notifies the webhooks and the subscribers of a room about
+// something that happened in it. The deliveries happen in the background, so
+// they never fail the request; they continue the trace of ctx.
+func Publish(ctx context.Context, roomId, pinCode, event string, data interface{}) {
+	dispatcher := new(webhooks.Dispatcher)
+	container.Make(&dispatcher)
+
+	_ = dispatcher.Publish(ctx, roomId, pinCode, event, data)
+
+	broker := new(events.Broker)
+	container.Make(&broker)
+
+	broker.Publish(events.New(roomId, pinCode, event, data))
+}
+
+// @Summary Subscribe to the events of a room
+// @Description Server-Sent Events stream of what happens in the room from the moment of the subscription: the same events sent to the webhooks,
+// @Description with the name of the event in "event" and the payload of the webhooks in "data". Votes are announced without their card.
+// @Description The stream ends when the client falls behind, and when the server shuts down, after a server.shutdown event with a retry hint; clients are expected to reconnect.
+// @Description A player who follows the stream with their ID and token is online while it is open.
+// @Tags Rooms
+// @Param pincode path string true "Pin Code of the Room"
+// @Param player_id query string false "ID of the player following the stream"
+// @Param Authorization header string false "Bearer token of the player, with player_id"
+// @Produce text/event-stream
+// @Success 200 {object} events.Event "The data of each message"
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/events [get]
+func roomEvents(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	playerId := c.Query("player_id")
+	if len(playerId) > 0 {
+		player, err := FindPlayer(ctx, db, room.Id, playerId)
+		if err != nil {
+			return err
+		}
+		utils.SetPlayer(c, player.Id)
+
+		if !IsPlayer(c, player) {
+			return apierror.New(apierror.Unauthorized, "only the player can follow the events as the player")
+		}
+		if err := connect(ctx, db, room.Id, player.Id, 1); err != nil {
+			return err
+		}
+	}
+
+	broker := new(events.Broker)
+	container.Make(&broker)
+
+	stream, unsubscribe := broker.Subscribe(room.Id)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+
+	// The write timeout of the server would cut the stream, so every write
+	// gets its own
+	conn := c.Context().Conn()
+	writeTimeout := c.App().Config().WriteTimeout
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		if len(playerId) > 0 {
+			// The request is over, but the player must still go offline
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), Disconnect)
+				defer cancel()
+				_ = connect(ctx, db, room.Id, playerId, -1)
+			}()
+		}
+
+		keepAlive := time.NewTicker(KeepAlive)
+		defer keepAlive.Stop()
+
+		flush := func() bool {
+			if writeTimeout > 0 {
+				_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			return w.Flush() == nil
+		}
+
+		fmt.Fprint(w, ": connected\n\n")
+		if !flush() {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-stream:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					continue
+				}
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			if !flush() {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// connect counts the streams of events a player follows, adding delta to
+// them. The player is online while they follow any.
+func connect(ctx context.Context, db *firestore.Client, roomId, playerId string, delta int) error {
+	ref := roomRef(db, roomId).Collection("players").Doc(playerId)
+	return db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+
+		player := new(players.Player)
+		if err := snap.DataTo(player); err != nil {
+			return err
+		}
+		connections := player.Connections + delta
+		if connections < 0 {
+			connections = 0
+		}
+
+		err = tx.Update(ref, []firestore.Update{
+			{Path: "connections", Value: connections},
+			{Path: "online", Value: connections > 0},
+		})
+		if err != nil || player.Online == (connections > 0) {
+			return err
+		}
+		return tx.Update(roomRef(db, roomId), bumpVersion)
+	})
+}
+
+// writeEvent writes an event of a stream. The last one, sent when the server
+// shuts down, tells the clients when to reconnect.
+func writeEvent(w io.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.Event == events.EventShutdown {
+		fmt.Fprintf(w, "retry: %d\n", Reconnect.Milliseconds())
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Event, data)
+	return err
+}
@@ -0,0 +1,128 @@
+package rooms
+
+import (
+	"bufio"
+	"cloud.google.com/go/firestore"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/export"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"google.golang.org/api/iterator"
+)
+
+// @Summary Export the results of a room
+// @Tags Rooms
+// @Param pincode path string true "Pin Code of the Room"
+// @Param format query string false "Export format" Enums(csv, json, md) default(json)
+// @Produce json
+// @Produce text/csv
+// @Produce text/markdown
+// @Success 200 {file} file
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/export [get]
+func exportRoom(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	format, err := export.ParseFormat(c.Query("format", string(export.JSON)))
+	if err != nil {
+		return apierror.Invalid("format", err.Error())
+	}
+
+	pinCode := c.Params("pincode")
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, pinCode)
+	if err != nil {
+		return err
+	}
+
+	utils.SetRoom(c, room.Id)
+
+	roomRef := db.Collection("rooms").Doc(room.Id)
+
+	pls, err := RoomPlayers(ctx, db, room.Id)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Attachment(fmt.Sprintf("room-%s.%s", room.PinCode, format))
+
+	// The stories are written as they are read, so the whole session is never
+	// held in memory. They are streamed after the handler returns, when the
+	// deadline of the request no longer applies. Once streaming has started the
+	// status code can't change anymore, so a failure, like a client that went
+	// away, just ends the body early.
+	ctx = utils.Detach(ctx)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer, err := export.NewWriter(format, w)
+		if err != nil {
+			return
+		}
+		if err := writer.Begin(*room); err != nil {
+			return
+		}
+
+		iter := roomRef.Collection("stories").OrderBy("timestamp", firestore.Asc).Documents(ctx)
+		defer iter.Stop()
+
+		for {
+			snap, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return
+			}
+
+			var story stories.Story
+			if err := snap.DataTo(&story); err != nil {
+				return
+			}
+			story.Id = snap.Ref.ID
+
+			rds, err := storyRounds(ctx, roomRef, story.Id)
+			if err != nil {
+				return
+			}
+
+			if err := writer.Write(export.NewStory(story, rds, pls)); err != nil {
+				return
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+
+		_ = writer.End()
+	})
+
+	return nil
+}
+
+func storyRounds(ctx context.Context, roomRef *firestore.DocumentRef, storyId string) ([]rounds.Round, error) {
+	snaps, err := roomRef.Collection("rounds").Where("story_id", "==", storyId).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rds := make([]rounds.Round, len(snaps))
+	for i, snap := range snaps {
+		if err := snap.DataTo(&rds[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return rds, nil
+}
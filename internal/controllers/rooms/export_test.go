@@ -0,0 +1,151 @@
+package rooms
+
+import (
+	"cloud.google.com/go/firestore"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/export"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"testing"
+)
+
+func createExportRoom(assert *Assert.Assertions, estimate string) string {
+
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+	roomDoc := db.Collection("rooms").NewDoc()
+	_, err := roomDoc.Set(ctx, map[string]interface{}{
+		"name":      "Room",
+		"pincode":   pinCode,
+		"timestamp": firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	playerIds := make([]string, 0)
+	for _, name := range []string{"Ana", "Bob"} {
+		doc := roomDoc.Collection("players").NewDoc()
+		_, err = doc.Set(ctx, map[string]interface{}{
+			"name":      name,
+			"timestamp": firestore.ServerTimestamp,
+		})
+		assert.NoError(err)
+		playerIds = append(playerIds, doc.ID)
+	}
+
+	storyDoc := roomDoc.Collection("stories").NewDoc()
+	_, err = storyDoc.Set(ctx, map[string]interface{}{
+		"key":       "PKR-1",
+		"title":     "Export results",
+		"estimate":  estimate,
+		"timestamp": firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	for number, votes := range []map[string]interface{}{
+		{playerIds[0]: "3", playerIds[1]: "8"},
+		{playerIds[0]: "5", playerIds[1]: "5"},
+	} {
+		_, err = roomDoc.Collection("rounds").Doc(fmt.Sprint(number+1)).Set(ctx, map[string]interface{}{
+			"number":    number + 1,
+			"story_id":  storyDoc.ID,
+			"votes":     votes,
+			"revealed":  true,
+			"timestamp": firestore.ServerTimestamp,
+		})
+		assert.NoError(err)
+	}
+
+	return pinCode
+}
+
+func TestExportRoomAsJson(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode := createExportRoom(assert, "8")
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/export?format=json", pinCode), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Contains(res.Header.Get("Content-Type"), "application/json")
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	result := struct {
+		Stories []export.Story `json:"stories"`
+	}{}
+	assert.NoError(json.Unmarshal(bodyResp, &result))
+
+	assert.Len(result.Stories, 1)
+	assert.Equal("PKR-1", result.Stories[0].Key)
+	assert.Equal("8", result.Stories[0].Estimate)
+	assert.Equal(2, result.Stories[0].Rounds)
+	assert.Equal(map[string]int{"5": 2}, result.Stories[0].Distribution)
+	assert.Equal([]string{"Ana", "Bob"}, result.Stories[0].Participants)
+}
+
+func TestExportRoomAsCsv(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode := createExportRoom(assert, "")
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/export?format=csv", pinCode), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Contains(res.Header.Get("Content-Disposition"), fmt.Sprintf("room-%s.csv", pinCode))
+
+	records, err := csv.NewReader(res.Body).ReadAll()
+	assert.NoError(err)
+	assert.Len(records, 2)
+	// Without an estimate, the story takes the card agreed on
+	assert.Equal([]string{"PKR-1", "Export results", "", "5", "2", "1", "0", "5=2", "Ana; Bob"}, records[1])
+}
+
+func TestExportRoomInvalidFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/export?format=xml", pinCode), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the format must be one of csv, json or md",
+		Fields: []models.FieldError{
+			{Field: "format", Message: "the format must be one of csv, json or md"},
+		},
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestExportRoomThatRoomNotExists(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/export", pinCode), nil)
+	res, err := app.Test(req, 30000)
+
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
@@ -0,0 +1,70 @@
+package rooms
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrRoomNotFound   = apierror.New(apierror.RoomNotFound, "room not found")
+	ErrPlayerNotFound = apierror.New(apierror.NotFound, "player not found")
+)
+
+// FindRoom looks a room up by its pin code.
+func FindRoom(ctx context.Context, db *firestore.Client, pinCode string) (*rooms.Room, error) {
+	snap, err := db.Collection("rooms").Where("pincode", "==", pinCode).Limit(1).Documents(ctx).Next()
+	if err == iterator.Done {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	room := new(rooms.Room)
+	if err := snap.DataTo(room); err != nil {
+		return nil, errors.New("unable to retrieve room information")
+	}
+	room.Id = snap.Ref.ID
+
+	return room, nil
+}
+
+// FindPlayer reads a player of a room.
+func FindPlayer(ctx context.Context, db *firestore.Client, roomId, playerId string) (*players.Player, error) {
+	snap, err := db.Collection("rooms").Doc(roomId).Collection("players").Doc(playerId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	player := new(players.Player)
+	if err := snap.DataTo(player); err != nil {
+		return nil, err
+	}
+	player.Id = snap.Ref.ID
+
+	return player, nil
+}
+
+// IsFacilitator tells whether the request carries the token handed out when
+// the room was created.
+func IsFacilitator(c *fiber.Ctx, room *rooms.Room) bool {
+	return utils.CheckToken(utils.BearerToken(c.Get(fiber.HeaderAuthorization)), room.FacilitatorToken)
+}
+
+// IsPlayer tells whether the request carries the token handed out when the
+// player joined the room.
+func IsPlayer(c *fiber.Ctx, player *players.Player) bool {
+	return utils.CheckToken(utils.BearerToken(c.Get(fiber.HeaderAuthorization)), player.Token)
+}
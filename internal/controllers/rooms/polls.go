@@ -0,0 +1,336 @@
+package rooms
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/polls"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+var (
+	ErrPollNotFound = apierror.New(apierror.NotFound, "poll not found")
+	ErrPollClosed   = apierror.New(apierror.Conflict, "the poll was already closed")
+)
+
+func pollRef(db *firestore.Client, roomId, pollId string) *firestore.DocumentRef {
+	return roomRef(db, roomId).Collection("polls").Doc(pollId)
+}
+
+// OpenPoll opens a poll in a room. The yes/no polls get their options.
+func OpenPoll(ctx context.Context, db *firestore.Client, room *rooms.Room, body *polls.PollNewRequest) (*polls.Poll, error) {
+	poll := &polls.Poll{
+		Question:  body.Question,
+		Kind:      body.Kind,
+		Options:   body.Options,
+		Dots:      body.Dots,
+		CreatedAt: time.Now().UTC(),
+		Ballots:   make(map[string][]string),
+	}
+	if poll.Kind == polls.YesNo {
+		poll.Options = polls.YesNoOptions
+	}
+
+	doc, _, err := roomRef(db, room.Id).Collection("polls").Add(ctx, map[string]interface{}{
+		"question":  poll.Question,
+		"kind":      poll.Kind,
+		"options":   poll.Options,
+		"dots":      poll.Dots,
+		"closed":    false,
+		"timestamp": poll.CreatedAt,
+		"ballots":   poll.Ballots,
+	})
+	if err != nil {
+		return nil, err
+	}
+	poll.Id = doc.ID
+
+	metrics.PollsOpened.Inc()
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventPollOpened, poll)
+
+	return poll, nil
+}
+
+// GetPoll reads a poll of a room, with its results once it is closed.
+func GetPoll(ctx context.Context, db *firestore.Client, roomId, pollId string) (*polls.Poll, error) {
+	snap, err := pollRef(db, roomId, pollId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrPollNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return readPoll(snap)
+}
+
+func readPoll(snap *firestore.DocumentSnapshot) (*polls.Poll, error) {
+	poll := new(polls.Poll)
+	if err := snap.DataTo(poll); err != nil {
+		return nil, err
+	}
+	poll.Id = snap.Ref.ID
+	poll.Tally()
+
+	return poll, nil
+}
+
+// VotePoll records the choices of a player in an open poll, replacing the
+// previous ones. The event tells who voted, not the choices.
+func VotePoll(ctx context.Context, db *firestore.Client, room *rooms.Room, pollId, playerId string, choices []string) error {
+	ref := pollRef(db, room.Id, pollId)
+	err := db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrPollNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		poll, err := readPoll(snap)
+		if err != nil {
+			return err
+		}
+		if poll.Closed {
+			return ErrPollClosed
+		}
+		if problem := poll.Check(choices); len(problem) > 0 {
+			return apierror.Invalid("choices", problem)
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{FieldPath: firestore.FieldPath{"ballots", playerId}, Value: choices},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventPollVoted, polls.PollBallot{
+		PollId:   pollId,
+		PlayerId: playerId,
+	})
+
+	return nil
+}
+
+// ClosePoll ends the votes of a poll and shows its results. Closing a poll
+// twice is an error.
+func ClosePoll(ctx context.Context, db *firestore.Client, room *rooms.Room, pollId string) (*polls.Poll, error) {
+	ref := pollRef(db, room.Id, pollId)
+
+	var poll *polls.Poll
+	err := db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrPollNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		poll, err = readPoll(snap)
+		if err != nil {
+			return err
+		}
+		if poll.Closed {
+			return ErrPollClosed
+		}
+
+		now := time.Now().UTC()
+		poll.Closed = true
+		poll.ClosedAt = &now
+		return tx.Update(ref, []firestore.Update{
+			{Path: "closed", Value: true},
+			{Path: "closed_at", Value: poll.ClosedAt},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	poll.Tally()
+
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventPollClosed, poll)
+
+	return poll, nil
+}
+
+// @Summary Open a poll
+// @Description Opens a quick decision in the room, apart from the rounds: a single choice, a multi choice where every player places dots, or a yes/no/abstain poll. Only the facilitator can open polls.
+// @Tags Polls
+// @Param pincode path string true "Pin Code of the Room"
+// @Security FacilitatorToken
+// @Param body body polls.PollNewRequest true "The poll"
+// @Accept json
+// @Produce json
+// @Success 201 {object} polls.Poll
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/polls [post]
+func newPoll(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	body := new(polls.PollNewRequest)
+	if err := c.BodyParser(body); err != nil {
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
+	}
+
+	if err := body.Validate(); err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	if !IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can open polls")
+	}
+
+	poll, err := OpenPoll(ctx, db, room, body)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(201).JSON(poll)
+}
+
+// @Summary Get a poll
+// @Description How many players voted in the poll and, once it is closed, its results.
+// @Tags Polls
+// @Param pincode path string true "Pin Code of the Room"
+// @Param id path string true "ID of the Poll"
+// @Produce json
+// @Success 200 {object} polls.Poll
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/polls/{id} [get]
+func getPoll(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	poll, err := GetPoll(ctx, db, room.Id, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(poll)
+}
+
+// @Summary Vote in a poll
+// @Description Records the choices of a player, replacing the previous ones while the poll is open: one option, or one per dot in a multi choice poll.
+// @Tags Polls
+// @Param pincode path string true "Pin Code of the Room"
+// @Param id path string true "ID of the Poll"
+// @Security PlayerToken
+// @Param body body polls.PollVoteRequest true "The choices"
+// @Accept json
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/polls/{id}/votes [post]
+func votePoll(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	body := new(polls.PollVoteRequest)
+	if err := c.BodyParser(body); err != nil {
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
+	}
+
+	if err := body.Validate(); err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	player, err := FindPlayer(ctx, db, room.Id, body.PlayerId)
+	if err != nil {
+		return err
+	}
+	utils.SetPlayer(c, player.Id)
+
+	if !IsPlayer(c, player) {
+		return apierror.New(apierror.Unauthorized, "only the player can cast their vote")
+	}
+
+	if err := VotePoll(ctx, db, room, c.Params("id"), player.Id, body.Choices); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// @Summary Close a poll
+// @Description Ends the votes of a poll and shows its results. Only the facilitator can close polls.
+// @Tags Polls
+// @Param pincode path string true "Pin Code of the Room"
+// @Param id path string true "ID of the Poll"
+// @Security FacilitatorToken
+// @Produce json
+// @Success 200 {object} polls.Poll
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/polls/{id}/close [post]
+func closePoll(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	if !IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can close polls")
+	}
+
+	poll, err := ClosePoll(ctx, db, room, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(poll)
+}
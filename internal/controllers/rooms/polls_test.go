@@ -0,0 +1,83 @@
+package rooms
+
+import (
+	"encoding/json"
+	"fmt"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/polls"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"io/ioutil"
+	"testing"
+)
+
+func TestPlayPollOverHttp(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	res, err := post(fmt.Sprintf("/rooms/%s/join", room.PinCode), "", rooms.RoomJoinRequest{PlayerName: "Ana"})
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, joined))
+
+	res, err = post(fmt.Sprintf("/rooms/%s/polls", room.PinCode), joined.PlayerToken, polls.PollNewRequest{Question: "Ship it?", Kind: polls.YesNo})
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/polls", room.PinCode), room.FacilitatorToken, polls.PollNewRequest{Question: "Ship it?", Kind: polls.YesNo})
+	assert.NoError(err)
+	assert.Equal(201, res.StatusCode)
+	poll := new(polls.Poll)
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, poll))
+	assert.Equal(polls.YesNoOptions, poll.Options)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/polls/%s/votes", room.PinCode, poll.Id), joined.PlayerToken, polls.PollVoteRequest{
+		PlayerId: joined.PlayerId,
+		Choices:  []string{"maybe"},
+	})
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/polls/%s/votes", room.PinCode, poll.Id), joined.PlayerToken, polls.PollVoteRequest{
+		PlayerId: joined.PlayerId,
+		Choices:  []string{"yes"},
+	})
+	assert.NoError(err)
+	assert.Equal(204, res.StatusCode)
+
+	open, err := GetPoll(ctx, db, room.RoomId, poll.Id)
+	assert.NoError(err)
+	assert.Equal(1, open.Voters)
+	assert.Nil(open.Results)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/polls/%s/close", room.PinCode, poll.Id), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	closed := new(polls.Poll)
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, closed))
+	assert.True(closed.Closed)
+	assert.Equal([]polls.Result{{Option: "yes", Votes: 1}, {Option: "no"}, {Option: "abstain"}}, closed.Results)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/polls/%s/votes", room.PinCode, poll.Id), joined.PlayerToken, polls.PollVoteRequest{
+		PlayerId: joined.PlayerId,
+		Choices:  []string{"no"},
+	})
+	assert.NoError(err)
+	assert.Equal(409, res.StatusCode)
+}
+
+func TestGetPollNotFound(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	_, err = GetPoll(ctx, db, room.RoomId, "missing")
+	assert.Equal(ErrPollNotFound, err)
+}
@@ -3,20 +3,26 @@ package rooms
 import (
 	"cloud.google.com/go/firestore"
 	"context"
-	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/pagination"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/validation"
+	"math"
 	"math/rand"
+	"strconv"
 	"time"
 )
 
-var ctx context.Context
-
 // @Summary Create a new room
+// @Description An anonymous room reveals its rounds as the cards played, shuffled, without who played them. A room with confidence asks every player how confident they are, from 1 to 5, with their card.
 // @Tags Rooms
 // @Param room body rooms.RoomNewRequest true "Create a new room"
 // @Accept json
@@ -24,40 +30,73 @@ var ctx context.Context
 // @Success 200 {object} rooms.RoomNewResponse
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Router /rooms [post]
+// @Router /v1/rooms [post]
 func newRoom(c *fiber.Ctx) error {
+	ctx := utils.Context(c)
+
 	body := new(rooms.RoomNewRequest)
 	if err := c.BodyParser(body); err != nil {
-		_ = utils.SendError(c, 500, err)
-		return nil
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
 	}
 
 	if err := body.Validate(); err != nil {
-		_ = utils.SendError(c, 400, err)
-		return nil
+		return err
 	}
 
 	db := new(firestore.Client)
 	container.Make(&db)
 
+	response, err := CreateRoom(ctx, db, body.Name, body.Settings)
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, response.RoomId)
+
+	return c.JSON(response)
+}
+
+// CreateRoom creates a room with a new pin code and facilitator token. It is
+// shared by every way of creating rooms, not only the REST endpoint.
+func CreateRoom(ctx context.Context, db *firestore.Client, name string, settings rooms.Settings) (*rooms.RoomNewResponse, error) {
+
+	conf := new(config.Config)
+	container.Make(&conf)
+
 	seed := rand.NewSource(time.Now().UnixNano())
 	rd := rand.New(seed)
-	pinCode := fmt.Sprintf("%06d", rd.Intn(999999))
+	pinCode := fmt.Sprintf("%0*d", conf.PinLength, rd.Intn(int(math.Pow10(conf.PinLength))-1))
+
+	token, err := utils.NewToken()
+	if err != nil {
+		return nil, err
+	}
 
 	doc, _, err := db.Collection("rooms").Add(ctx, map[string]interface{}{
-		"name":      body.Name,
-		"pincode":   pinCode,
-		"timestamp": firestore.ServerTimestamp,
+		"name":              name,
+		"pincode":           pinCode,
+		"facilitator_token": utils.HashToken(token),
+		"timestamp":         firestore.ServerTimestamp,
+		"version":           1,
+		"anonymous":         settings.Anonymous,
+		"confidence":        settings.Confidence,
 	})
 	if err != nil {
-		_ = utils.SendError(c, 500, err)
-		return nil
+		return nil, err
 	}
 
-	return c.JSON(rooms.RoomNewResponse{
-		RoomId:  doc.ID,
-		PinCode: pinCode,
+	metrics.RoomsCreated.Inc()
+	Publish(ctx, doc.ID, pinCode, webhooks.EventRoomCreated, rooms.Room{
+		Id:       doc.ID,
+		Name:     name,
+		PinCode:  pinCode,
+		Settings: settings,
 	})
+
+	return &rooms.RoomNewResponse{
+		RoomId:           doc.ID,
+		PinCode:          pinCode,
+		FacilitatorToken: token,
+	}, nil
 }
 
 // @Summary Join a room
@@ -70,18 +109,18 @@ func newRoom(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Router /rooms/{pincode}/join [post]
+// @Router /v1/rooms/{pincode}/join [post]
 func joinRoom(c *fiber.Ctx) error {
 
+	ctx := utils.Context(c)
+
 	body := new(rooms.RoomJoinRequest)
 	if err := c.BodyParser(body); err != nil {
-		_ = utils.SendError(c, 500, err)
-		return nil
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
 	}
 
 	if err := body.Validate(); err != nil {
-		_ = utils.SendError(c, 400, err)
-		return nil
+		return err
 	}
 
 	db := new(firestore.Client)
@@ -89,86 +128,200 @@ func joinRoom(c *fiber.Ctx) error {
 
 	pinCode := c.Params("pincode")
 
-	roomSnap, err := db.Collection("rooms").Where("pincode", "==", pinCode).Limit(1).Documents(ctx).Next()
-	if err != nil || !roomSnap.Exists() {
-		_ = utils.SendError(c, 404, errors.New("room not found"))
-		return nil
+	room, err := FindRoom(ctx, db, pinCode)
+	if err != nil {
+		return err
 	}
 
-	roomId := roomSnap.Ref.ID
+	roomId := room.Id
+	utils.SetRoom(c, roomId)
 
-	var room rooms.Room
-	err = roomSnap.DataTo(&room)
+	token, err := utils.NewToken()
 	if err != nil {
-		_ = utils.SendError(c, 500, errors.New("unable to retrieve room information"))
-		return nil
+		return err
 	}
-	room.Id = roomId
 
-	player, _, err := db.Collection("rooms").Doc(roomId).Collection("players").Add(ctx, map[string]interface{}{
+	player := roomRef(db, roomId).Collection("players").NewDoc()
+	err = AddPlayer(ctx, db, player, map[string]interface{}{
 		"name":      body.PlayerName,
+		"role":      body.Role,
+		"token":     utils.HashToken(token),
 		"timestamp": firestore.ServerTimestamp,
 	})
 	if err != nil {
-		_ = utils.SendError(c, 500, err)
-		return nil
+		return err
 	}
 
+	utils.SetPlayer(c, player.ID)
+	metrics.PlayersJoined.Inc()
+	Publish(ctx, roomId, pinCode, webhooks.EventPlayerJoined, players.Player{
+		Id:   player.ID,
+		Name: body.PlayerName,
+		Role: body.Role,
+	})
+
 	return c.JSON(rooms.RoomJoinResponse{
-		Room:       room,
-		PlayerId:   player.ID,
-		PlayerName: body.PlayerName,
+		Room:        *room,
+		PlayerId:    player.ID,
+		PlayerName:  body.PlayerName,
+		PlayerToken: token,
 	})
 }
 
 // @Summary Get players from a room
+// @Description The players come in pages, linked by the Link header with rel="next" until the last one.
+// @Description The ETag is the version of the room, bumped on every change of its players, rounds or votes.
+// @Description With If-None-Match, or since, the players are only sent when the room changed; otherwise the answer is 304.
+// @Description With wait too, the request is held until the room changes, up to a minute.
 // @Tags Rooms
 // @Param pincode path string true "Pin Code of the Room"
+// @Param limit query int false "Players per page, up to 500" default(100)
+// @Param cursor query string false "Where the page starts, from the Link of the previous one"
+// @Param sort query string false "Order of the players" Enums(joined_at, name) default(joined_at)
+// @Param order query string false "Direction of the order" Enums(asc, desc) default(asc)
+// @Param role query string false "Only the players with the role" Enums(voter, observer)
+// @Param online query bool false "Only the players following the events of the room, or the others"
+// @Param voted query bool false "Only the players who voted in the latest round, or who didn't"
+// @Param If-None-Match header string false "ETag of the players the client has"
+// @Param since query int false "Version of the room the client has, as in the ETag"
+// @Param wait query string false "How long to wait for a change, e.g. 30s"
 // @Produce json
 // @Success 200 {array} players.Player
+// @Header 200 {string} ETag "Version of the room, as a quoted number"
+// @Header 200 {string} Link "The next page"
+// @Success 304
+// @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Router /rooms/{pincode}/players [get]
+// @Router /v1/rooms/{pincode}/players [get]
 func getPlayers(c *fiber.Ctx) error {
 
+	ctx := utils.Context(c)
+
 	pinCode := c.Params("pincode")
 
+	page, err := pagination.Parse(c, playerSorts, "joined_at")
+	if err != nil {
+		return err
+	}
+
+	filters, err := playerFilters(c)
+	if err != nil {
+		return err
+	}
+
+	wait, since, known, err := longPoll(c)
+	if err != nil {
+		return err
+	}
+
 	db := new(firestore.Client)
 	container.Make(&db)
 
-	roomSnap, err := db.Collection("rooms").Where("pincode", "==", pinCode).Limit(1).Documents(ctx).Next()
-	if err != nil || !roomSnap.Exists() {
-		_ = utils.SendError(c, 404, errors.New("room not found"))
-		return nil
+	room, err := FindRoom(ctx, db, pinCode)
+	if err != nil {
+		return err
 	}
 
-	roomId := roomSnap.Ref.ID
+	roomId := room.Id
+	utils.SetRoom(c, roomId)
 
-	snaps, err := db.Collection("rooms").Doc(roomId).Collection("players").OrderBy("timestamp", firestore.Asc).Documents(ctx).GetAll()
+	version := room.Version
+	if wait > 0 && version <= since {
+		var cancel context.CancelFunc
+		version, cancel, err = waitForChange(c, db, roomId, since, wait)
+		defer cancel()
+		if err != nil {
+			return err
+		}
+		ctx = utils.Context(c)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(version))
+	if c.Fresh() || (known && version <= since) {
+		return c.SendStatus(304)
+	}
+
+	col := roomRef(db, roomId).Collection("players")
+	query := col.Query
+	for _, filter := range filters {
+		query = query.Where(filter.path, "==", filter.value)
+	}
+
+	snaps, next, err := page.List(ctx, col, query, nil)
 	if err != nil {
-		_ = utils.SendError(c, 500, err)
-		return nil
+		return err
 	}
+
 	pls := make([]players.Player, len(snaps))
 	for i, snap := range snaps {
 		if err := snap.DataTo(&pls[i]); err != nil {
-			_ = utils.SendError(c, 500, err)
-			return nil
+			return err
 		}
 		pls[i].Id = snap.Ref.ID
 	}
 
+	pagination.Link(c, next)
 	return c.JSON(pls)
 }
 
+// The sorts of the players, and the fields they order by
+var playerSorts = map[string]string{
+	"joined_at": "timestamp",
+	"name":      "name",
+}
+
+type playerFilter struct {
+	path  string
+	value interface{}
+}
+
+// playerFilters reads the filters of the players by role, by whether they
+// are online and by whether they voted in the latest round. They are all
+// equalities, so Firestore filters the players while ordering them by any
+// field.
+func playerFilters(c *fiber.Ctx) ([]playerFilter, error) {
+	filters := make([]playerFilter, 0)
+
+	if value := c.Query("role"); len(value) > 0 {
+		if problem := validation.OneOf(players.Roles...)(value); len(problem) > 0 {
+			return nil, apierror.Invalid("role", "role "+problem)
+		}
+		filters = append(filters, playerFilter{path: "role", value: value})
+	}
+
+	for _, name := range []string{"online", "voted"} {
+		value := c.Query(name)
+		if len(value) == 0 {
+			continue
+		}
+		is, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, apierror.Invalid(name, name+" must be true or false")
+		}
+		filters = append(filters, playerFilter{path: name, value: is})
+	}
+
+	return filters, nil
+}
+
 // Registrar endpoints
 func Register(router fiber.Router) {
 
-	ctx = context.Background()
-
 	room := router.Group("/rooms")
 
 	room.Post("", newRoom)
 	room.Post(":pincode/join", joinRoom)
 	room.Get(":pincode/players", getPlayers)
+	room.Get(":pincode/export", exportRoom)
+	room.Get(":pincode/events", roomEvents)
+	room.Post(":pincode/rounds", newRound)
+	room.Post(":pincode/rounds/:n/votes", vote)
+	room.Post(":pincode/rounds/:n/reveal", reveal)
+	room.Post(":pincode/rounds/:n/revote", reVote)
+	room.Get(":pincode/rounds/:n/card", getRoundCard)
+	room.Post(":pincode/polls", newPoll)
+	room.Get(":pincode/polls/:id", getPoll)
+	room.Post(":pincode/polls/:id/votes", votePoll)
+	room.Post(":pincode/polls/:id/close", closePoll)
 }
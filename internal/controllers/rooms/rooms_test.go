@@ -11,44 +11,72 @@ import (
 	"github.com/golobby/container"
 	Assert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	_ "github.com/thiagopereiramartinez/scrumpoker-run.api/api"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/openapi"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	apiUtils "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
 	"golang.org/x/net/nettest"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"os"
+	"strings"
 	"testing"
 )
 
 var app *fiber.App
 var db *firestore.Client
+var ctx context.Context
+var baseUrl string
 
 func TestMain(m *testing.M) {
 
 	ctx = context.Background()
 
-	_ = di.SetupDependencies()
+	_ = di.SetupDependencies(config.Default())
 	container.Make(&db)
 
+	// Every request of the tests, and its response, is checked against the
+	// published document, where the routes are under /v1
+	document, err := openapi.Document()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	contract, err := test.NewContract(document, "/v1")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	app = fiber.New(fiber.Config{
 		DisableStartupMessage: true,
+		ErrorHandler:          apiUtils.ErrorHandler,
 	})
+	app.Use(contract.Handler())
 	Register(app)
 
 	listener, _ := nettest.NewLocalListener("tcp")
+	baseUrl = fmt.Sprintf("http://%s", listener.Addr())
 	go func() {
 		_ = app.Listener(listener)
 	}()
 
-	m.Run()
+	code := m.Run()
+	if report := contract.Report(); len(report) > 0 {
+		fmt.Println(report)
+		code = 1
+	}
+
+	db.Close()
+	_ = app.Shutdown()
 
-	defer func() {
-		db.Close()
-		_ = app.Shutdown()
-	}()
+	os.Exit(code)
 }
 
 func TestNewRoomValid(t *testing.T) {
@@ -73,12 +101,15 @@ func TestNewRoomValid(t *testing.T) {
 
 	assert.NotEmpty(response.RoomId)
 	assert.NotEmpty(response.PinCode)
+	assert.NotEmpty(response.FacilitatorToken)
 
 	snap, err := db.Collection("rooms").Doc(response.RoomId).Get(ctx)
 	assert.NoError(err)
 	assert.True(snap.Exists())
 	assert.Equal("Room", snap.Data()["name"])
 	assert.Equal(response.PinCode, snap.Data()["pincode"])
+	assert.NotEmpty(snap.Data()["facilitator_token"])
+	assert.NotEqual(response.FacilitatorToken, snap.Data()["facilitator_token"])
 	assert.NotEmpty(snap.Data()["timestamp"])
 }
 
@@ -99,8 +130,12 @@ func TestNewRoomNameIsEmpty(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    400,
-		Message: "the name of the room is required",
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the room is required",
+		Fields: []models.FieldError{
+			{Field: "name", Message: "the name of the room is required"},
+		},
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -122,8 +157,12 @@ func TestNewRoomNameIsBlank(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    400,
-		Message: "the name of the room is required",
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the room is required",
+		Fields: []models.FieldError{
+			{Field: "name", Message: "the name of the room is required"},
+		},
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -145,8 +184,12 @@ func TestNewRoomInvalidJsonBody(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    400,
-		Message: "the name of the room is required",
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the room is required",
+		Fields: []models.FieldError{
+			{Field: "name", Message: "the name of the room is required"},
+		},
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -164,12 +207,13 @@ func TestNewRoomInvalidContentType(t *testing.T) {
 	res, err := app.Test(req, 30000)
 
 	assert.NoError(err)
-	assert.Equal(500, res.StatusCode)
+	assert.Equal(400, res.StatusCode)
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    500,
-		Message: "Unprocessable Entity",
+		Code:      400,
+		ErrorCode: "invalid_body",
+		Message:   "the body of the request must be JSON",
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -223,6 +267,8 @@ func TestJoinRoomValid(t *testing.T) {
 	assert.True(playerSnap.Exists())
 
 	assert.Equal(result.PlayerName, playerSnap.Data()["name"])
+	assert.NotEmpty(result.PlayerToken)
+	assert.Equal(apiUtils.HashToken(result.PlayerToken), playerSnap.Data()["token"])
 }
 
 func TestJoinRoomNameIsEmpty(t *testing.T) {
@@ -243,8 +289,12 @@ func TestJoinRoomNameIsEmpty(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    400,
-		Message: "the name of the player is required",
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the player is required",
+		Fields: []models.FieldError{
+			{Field: "player_name", Message: "the name of the player is required"},
+		},
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -267,8 +317,12 @@ func TestJoinRoomNameIsBlank(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    400,
-		Message: "the name of the player is required",
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the player is required",
+		Fields: []models.FieldError{
+			{Field: "player_name", Message: "the name of the player is required"},
+		},
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -291,8 +345,12 @@ func TestJoinRoomInvalidJsonBody(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    400,
-		Message: "the name of the player is required",
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the player is required",
+		Fields: []models.FieldError{
+			{Field: "player_name", Message: "the name of the player is required"},
+		},
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -311,12 +369,13 @@ func TestJoinRoomInvalidContentType(t *testing.T) {
 	res, err := app.Test(req, 30000)
 
 	assert.NoError(err)
-	assert.Equal(500, res.StatusCode)
+	assert.Equal(400, res.StatusCode)
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    500,
-		Message: "Unprocessable Entity",
+		Code:      400,
+		ErrorCode: "invalid_body",
+		Message:   "the body of the request must be JSON",
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -339,8 +398,9 @@ func TestJoinRoomThatRoomNotExists(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    404,
-		Message: "room not found",
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -398,6 +458,128 @@ func TestGetPlayersFromRoom(t *testing.T) {
 	}
 }
 
+func joinRequest(pinCode, name string) *http.Request {
+	body, _ := json.Marshal(rooms.RoomJoinRequest{
+		PlayerName: name,
+	})
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/rooms/%s/join", pinCode), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestGetPlayersInPages(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	for _, name := range []string{"Carla", "Ana", "Bruno"} {
+		_, err := app.Test(joinRequest(room.PinCode, name), 30000)
+		assert.NoError(err)
+	}
+
+	names := make([]string, 0)
+	url := fmt.Sprintf("/rooms/%s/players?sort=name&order=desc&limit=2", room.PinCode)
+	for pages := 0; len(url) > 0; pages++ {
+		assert.Less(pages, 2)
+
+		req, _ := http.NewRequest("GET", url, nil)
+		res, err := app.Test(req, 30000)
+		if !assert.NoError(err) || !assert.Equal(200, res.StatusCode) {
+			return
+		}
+
+		pls := make([]players.Player, 0)
+		assert.NoError(json.NewDecoder(res.Body).Decode(&pls))
+		for _, player := range pls {
+			names = append(names, player.Name)
+		}
+
+		url = ""
+		if link := res.Header.Get("Link"); len(link) > 0 {
+			url = link[1:strings.Index(link, ">")]
+		}
+	}
+	assert.Equal([]string{"Carla", "Bruno", "Ana"}, names)
+}
+
+func getPlayerNames(assert *Assert.Assertions, pinCode, query string) []string {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/players%s", pinCode, query), nil)
+	res, err := app.Test(req, 30000)
+	assert.NoError(err)
+
+	pls := make([]players.Player, 0)
+	assert.NoError(json.NewDecoder(res.Body).Decode(&pls))
+
+	names := make([]string, len(pls))
+	for i, player := range pls {
+		names[i] = player.Name
+	}
+	return names
+}
+
+func TestGetPlayersWhoVoted(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	ids := make(map[string]string)
+	for _, name := range []string{"Ana", "Bruno"} {
+		res, err := app.Test(joinRequest(room.PinCode, name), 30000)
+		assert.NoError(err)
+		joined := new(rooms.RoomJoinResponse)
+		assert.NoError(json.NewDecoder(res.Body).Decode(joined))
+		ids[name] = joined.PlayerId
+	}
+
+	_, err := StartRound(ctx, db, room, "")
+	assert.NoError(err)
+	assert.NoError(Vote(ctx, db, room, 1, ids["Bruno"], "5", 0))
+
+	assert.Equal([]string{"Bruno"}, getPlayerNames(assert, room.PinCode, "?voted=true"))
+	assert.Equal([]string{"Ana"}, getPlayerNames(assert, room.PinCode, "?voted=false"))
+
+	// Nobody voted in the next round yet
+	_, err = StartRound(ctx, db, room, "")
+	assert.NoError(err)
+	assert.Empty(getPlayerNames(assert, room.PinCode, "?voted=true"))
+	assert.Equal([]string{"Ana", "Bruno"}, getPlayerNames(assert, room.PinCode, "?voted=false"))
+
+	// A late vote in the previous round doesn't count
+	assert.NoError(Vote(ctx, db, room, 1, ids["Ana"], "3", 0))
+	assert.Empty(getPlayerNames(assert, room.PinCode, "?voted=true"))
+}
+
+func TestGetPlayersByRole(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	for name, role := range map[string]string{"Ana": "", "Bruno": "observer"} {
+		body, _ := json.Marshal(rooms.RoomJoinRequest{PlayerName: name, Role: role})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/rooms/%s/join", room.PinCode), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, 30000)
+		assert.NoError(err)
+		assert.Equal(200, res.StatusCode)
+	}
+
+	assert.Equal([]string{"Ana"}, getPlayerNames(assert, room.PinCode, "?role=voter"))
+	assert.Equal([]string{"Bruno"}, getPlayerNames(assert, room.PinCode, "?role=observer&voted=false"))
+}
+
+func TestGetPlayersInvalidQuery(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for _, query := range []string{"?sort=score", "?order=up", "?voted=maybe", "?online=1.5", "?role=facilitator", "?cursor=nope"} {
+		req, _ := http.NewRequest("GET", "/rooms/123456/players"+query, nil)
+		res, err := app.Test(req, 30000)
+		assert.NoError(err)
+		assert.Equal(400, res.StatusCode, query)
+	}
+}
+
 func TestGetPlayersThatRoomNotExists(t *testing.T) {
 
 	assert := Assert.New(t)
@@ -411,8 +593,9 @@ func TestGetPlayersThatRoomNotExists(t *testing.T) {
 
 	bodyResp, _ := ioutil.ReadAll(res.Body)
 	jsonBodyResp, _ := models.Error{
-		Code:    404,
-		Message: "room not found",
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
 	}.ToJson()
 	assert.Equal(jsonBodyResp, string(bodyResp))
 }
@@ -426,6 +609,17 @@ func TestRegisterRoutes(t *testing.T) {
 	router.On("Post", "", mock.Anything).Return(router)
 	router.On("Post", ":pincode/join", mock.Anything).Return(router)
 	router.On("Get", ":pincode/players", mock.Anything).Return(router)
+	router.On("Get", ":pincode/export", mock.Anything).Return(router)
+	router.On("Get", ":pincode/events", mock.Anything).Return(router)
+	router.On("Post", ":pincode/rounds", mock.Anything).Return(router)
+	router.On("Post", ":pincode/rounds/:n/votes", mock.Anything).Return(router)
+	router.On("Post", ":pincode/rounds/:n/reveal", mock.Anything).Return(router)
+	router.On("Post", ":pincode/rounds/:n/revote", mock.Anything).Return(router)
+	router.On("Get", ":pincode/rounds/:n/card", mock.Anything).Return(router)
+	router.On("Post", ":pincode/polls", mock.Anything).Return(router)
+	router.On("Get", ":pincode/polls/:id", mock.Anything).Return(router)
+	router.On("Post", ":pincode/polls/:id/votes", mock.Anything).Return(router)
+	router.On("Post", ":pincode/polls/:id/close", mock.Anything).Return(router)
 
 	Register(router)
 
@@ -0,0 +1,656 @@
+package rooms
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRoundNotFound = apierror.New(apierror.NotFound, "round not found")
+	ErrRoundRevealed = apierror.New(apierror.Conflict, "the round was already revealed")
+	ErrInvalidCard   = apierror.Invalid("card", "the card is not part of the deck")
+	ErrRoundHidden   = apierror.New(apierror.Conflict, "the round must be revealed before voting again")
+	ErrRoundNotLast  = apierror.New(apierror.Conflict, "only the latest round can be voted again")
+	ErrObserver      = apierror.New(apierror.Conflict, "observers don't vote")
+	ErrVoteCast      = apierror.New(apierror.Conflict, "the vote of an anonymous round can't be changed")
+
+	ErrConfidenceMissing = apierror.Invalid("confidence", fmt.Sprintf("the room asks for a confidence from %d to %d with every vote", rounds.MinConfidence, rounds.MaxConfidence))
+	ErrConfidenceUnasked = apierror.Invalid("confidence", "the room doesn't ask for a confidence")
+)
+
+func roundRef(db *firestore.Client, roomId string, number int) *firestore.DocumentRef {
+	return db.Collection("rooms").Doc(roomId).Collection("rounds").Doc(strconv.Itoa(number))
+}
+
+// RoomPlayers returns the players of a room in the order they joined.
+func RoomPlayers(ctx context.Context, db *firestore.Client, roomId string) ([]players.Player, error) {
+	snaps, err := db.Collection("rooms").Doc(roomId).Collection("players").OrderBy("timestamp", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	pls := make([]players.Player, len(snaps))
+	for i, snap := range snaps {
+		if err := snap.DataTo(&pls[i]); err != nil {
+			return nil, err
+		}
+		pls[i].Id = snap.Ref.ID
+	}
+
+	return pls, nil
+}
+
+// StartRound opens the next round of a room for a story. Rounds are numbered
+// from 1 and the number is also the ID of the document.
+func StartRound(ctx context.Context, db *firestore.Client, room *rooms.Room, storyId string) (*rounds.Round, error) {
+	col := roomRef(db, room.Id).Collection("rounds")
+
+	round := new(rounds.Round)
+	err := db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		number := 1
+		snap, err := tx.Documents(col.OrderBy("number", firestore.Desc).Limit(1)).Next()
+		if err != nil && err != iterator.Done {
+			return err
+		}
+		if err == nil {
+			last, _ := snap.DataAt("number")
+			if n, ok := last.(int64); ok {
+				number = int(n) + 1
+			}
+		}
+
+		*round = rounds.Round{
+			Number:     number,
+			StoryId:    storyId,
+			Votes:      make(map[string]string),
+			CreatedAt:  time.Now().UTC(),
+			Anonymous:  room.Anonymous,
+			Attempt:    1,
+			Confidence: room.Confidence,
+		}
+		return createRound(tx, db, room.Id, round)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resetVoted(ctx, db, room.Id, round.Number); err != nil {
+		return nil, err
+	}
+
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventRoundStarted, round)
+
+	return round, nil
+}
+
+// createRound writes a new round in a transaction, with the version of its
+// room and the number of its latest round.
+func createRound(tx *firestore.Transaction, db *firestore.Client, roomId string, round *rounds.Round) error {
+	data := map[string]interface{}{
+		"number":    round.Number,
+		"story_id":  round.StoryId,
+		"revealed":  false,
+		"timestamp": round.CreatedAt,
+		"anonymous": round.Anonymous,
+		"attempt":   round.Attempt,
+	}
+	if round.Discussion > 0 {
+		data["discussion_seconds"] = round.Discussion
+	}
+	// An anonymous round keeps who voted apart from the cards
+	if round.Anonymous {
+		round.Votes = nil
+		round.Voters = []string{}
+		round.Cards = []string{}
+		data["voters"] = round.Voters
+		data["cards"] = round.Cards
+	} else {
+		data["votes"] = round.Votes
+	}
+	if round.Confidence {
+		data["confidence"] = true
+		if round.Anonymous {
+			round.ConfidenceLevels = []int{}
+			data["confidence_levels"] = round.ConfidenceLevels
+		} else {
+			round.Confidences = make(map[string]int)
+			data["confidences"] = round.Confidences
+		}
+	}
+
+	if err := tx.Create(roundRef(db, roomId, round.Number), data); err != nil {
+		return err
+	}
+	return tx.Update(roomRef(db, roomId), append([]firestore.Update{{Path: "round", Value: round.Number}}, bumpVersion...))
+}
+
+// resetVoted marks the players who voted in the previous rounds as not voted
+// in a new one. A player who votes in the new round meanwhile is left alone,
+// since the update only applies to the player as it was read.
+func resetVoted(ctx context.Context, db *firestore.Client, roomId string, number int) error {
+	it := roomRef(db, roomId).Collection("players").Where("voted", "==", true).Documents(ctx)
+	defer it.Stop()
+
+	reset := false
+	for {
+		snap, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if votedRound, _ := snap.DataAt("voted_round"); votedRound == int64(number) {
+			continue
+		}
+		_, err = snap.Ref.Update(ctx, []firestore.Update{{Path: "voted", Value: false}}, firestore.LastUpdateTime(snap.UpdateTime))
+		if status.Code(err) == codes.FailedPrecondition {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		reset = true
+	}
+
+	if !reset {
+		return nil
+	}
+	_, err := roomRef(db, roomId).Update(ctx, bumpVersion)
+	return err
+}
+
+// ReVote opens the next attempt at the story of a revealed round, after the
+// discussion of its votes, which stay in the round as the previous attempt.
+// Only the latest round of the room can be voted again.
+func ReVote(ctx context.Context, db *firestore.Client, room *rooms.Room, number int) (*rounds.Round, error) {
+	ref := roundRef(db, room.Id, number)
+
+	round := new(rounds.Round)
+	err := db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrRoundNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var previous rounds.Round
+		if err := snap.DataTo(&previous); err != nil {
+			return err
+		}
+		if !previous.Revealed {
+			return ErrRoundHidden
+		}
+
+		_, err = tx.Get(roundRef(db, room.Id, number+1))
+		if err == nil {
+			return ErrRoundNotLast
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		now := time.Now().UTC()
+		*round = rounds.Round{
+			Number:     number + 1,
+			StoryId:    previous.StoryId,
+			Votes:      make(map[string]string),
+			CreatedAt:  now,
+			Anonymous:  room.Anonymous,
+			Attempt:    previous.AttemptNumber() + 1,
+			Confidence: room.Confidence,
+		}
+		// The rounds revealed before the time was recorded have no discussion
+		if previous.RevealedAt != nil {
+			round.Discussion = now.Sub(*previous.RevealedAt).Seconds()
+		}
+		return createRound(tx, db, room.Id, round)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resetVoted(ctx, db, room.Id, round.Number); err != nil {
+		return nil, err
+	}
+
+	metrics.ReVotes.Inc()
+	if round.Discussion > 0 {
+		metrics.DiscussionDuration.Observe(round.Discussion)
+	}
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventRoundStarted, round)
+
+	return round, nil
+}
+
+// GetRound reads a round of a room.
+func GetRound(ctx context.Context, db *firestore.Client, roomId string, number int) (*rounds.Round, error) {
+	snap, err := roundRef(db, roomId, number).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrRoundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	round := new(rounds.Round)
+	if err := snap.DataTo(round); err != nil {
+		return nil, err
+	}
+
+	return round, nil
+}
+
+// Vote records the card of a player in a round, replacing any previous vote
+// of the player while the round is still hidden. The confidence goes with
+// the card when the round asks for it, and is 0 otherwise. Observers don't
+// vote. An anonymous round only records that the player voted and, apart,
+// the card, so a vote in it can't be replaced. A vote in the latest round
+// of the room marks the player as voted. The event tells who voted, not the
+// card.
+func Vote(ctx context.Context, db *firestore.Client, room *rooms.Room, number int, playerId, value string, confidence int) error {
+	if !rounds.IsCard(value) {
+		return ErrInvalidCard
+	}
+
+	ref := roundRef(db, room.Id, number)
+	playerRef := roomRef(db, room.Id).Collection("players").Doc(playerId)
+	err := db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrRoundNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		round := new(rounds.Round)
+		if err := snap.DataTo(round); err != nil {
+			return err
+		}
+		if round.Revealed {
+			return ErrRoundRevealed
+		}
+
+		playerSnap, err := tx.Get(playerRef)
+		if status.Code(err) == codes.NotFound {
+			return ErrPlayerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if role, _ := playerSnap.DataAt("role"); role == players.RoleObserver {
+			return ErrObserver
+		}
+
+		roomSnap, err := tx.Get(roomRef(db, room.Id))
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case round.Confidence && !rounds.IsConfidence(confidence):
+			return ErrConfidenceMissing
+		case !round.Confidence && confidence != 0:
+			return ErrConfidenceUnasked
+		}
+
+		var updates []firestore.Update
+		if round.Anonymous {
+			if !round.VoteAnonymously(playerId, value, confidence) {
+				return ErrVoteCast
+			}
+			updates = []firestore.Update{
+				{Path: "voters", Value: round.Voters},
+				{Path: "cards", Value: round.Cards},
+			}
+			if round.Confidence {
+				updates = append(updates, firestore.Update{Path: "confidence_levels", Value: round.ConfidenceLevels})
+			}
+		} else {
+			updates = []firestore.Update{
+				{FieldPath: firestore.FieldPath{"votes", playerId}, Value: value},
+			}
+			if round.Confidence {
+				updates = append(updates, firestore.Update{FieldPath: firestore.FieldPath{"confidences", playerId}, Value: confidence})
+			}
+		}
+
+		err = tx.Update(ref, updates)
+		if err != nil {
+			return err
+		}
+		if latest, _ := roomSnap.DataAt("round"); latest == int64(number) {
+			err = tx.Update(playerRef, []firestore.Update{
+				{Path: "voted", Value: true},
+				{Path: "voted_round", Value: number},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Update(roomRef(db, room.Id), bumpVersion)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.Votes.Inc()
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventVoteCast, rounds.Ballot{
+		Round:    number,
+		PlayerId: playerId,
+	})
+
+	return nil
+}
+
+// Reveal shows the votes of a round and notifies the webhooks with its
+// summary. Revealing a round twice is an error. The cards of an anonymous
+// round are shuffled, though they were never stored with their players.
+func Reveal(ctx context.Context, db *firestore.Client, room *rooms.Room, number int) (*rounds.Summary, error) {
+	ref := roundRef(db, room.Id, number)
+
+	round := new(rounds.Round)
+	err := db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrRoundNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := snap.DataTo(round); err != nil {
+			return err
+		}
+		if round.Revealed {
+			return ErrRoundRevealed
+		}
+
+		now := time.Now().UTC()
+		round.Revealed = true
+		round.RevealedAt = &now
+		updates := []firestore.Update{
+			{Path: "revealed", Value: true},
+			{Path: "revealed_at", Value: round.RevealedAt},
+		}
+		if round.Anonymous {
+			rd, err := utils.NewRand()
+			if err != nil {
+				return err
+			}
+			round.Shuffle(rd.Shuffle)
+			updates = append(updates, firestore.Update{Path: "cards", Value: round.Cards})
+			if round.Confidence {
+				updates = append(updates, firestore.Update{Path: "confidence_levels", Value: round.ConfidenceLevels})
+			}
+		}
+		err = tx.Update(ref, updates)
+		if err != nil {
+			return err
+		}
+		return tx.Update(roomRef(db, room.Id), bumpVersion)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := Summarize(ctx, db, room.Id, *round)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.RoundsRevealed.Inc()
+	Publish(ctx, room.Id, room.PinCode, webhooks.EventRoundRevealed, summary)
+
+	return summary, nil
+}
+
+// Summarize builds the summary of a round with the names of the players and
+// the title of the story.
+func Summarize(ctx context.Context, db *firestore.Client, roomId string, round rounds.Round) (*rounds.Summary, error) {
+	pls, err := RoomPlayers(ctx, db, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	title := ""
+	if len(round.StoryId) > 0 {
+		snap, err := db.Collection("rooms").Doc(roomId).Collection("stories").Doc(round.StoryId).Get(ctx)
+		if err == nil {
+			var story stories.Story
+			if err := snap.DataTo(&story); err == nil {
+				title = story.Title
+			}
+		} else if status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+	}
+
+	summary := rounds.NewSummary(round, title, pls)
+	return &summary, nil
+}
+
+func roundNumber(c *fiber.Ctx) (int, error) {
+	number, err := strconv.Atoi(c.Params("n"))
+	if err != nil || number < 1 {
+		return 0, apierror.Invalid("n", "the number of the round must be a positive integer")
+	}
+	return number, nil
+}
+
+// @Summary Start a round
+// @Description Opens the next round of the room, for a story or for none. Only the facilitator can start rounds.
+// @Tags Rounds
+// @Param pincode path string true "Pin Code of the Room"
+// @Security FacilitatorToken
+// @Param body body rounds.RoundNewRequest false "Story of the round"
+// @Accept json
+// @Produce json
+// @Success 201 {object} rounds.Round
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/rounds [post]
+func newRound(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	body := new(rounds.RoundNewRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(body); err != nil {
+			return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
+		}
+	}
+
+	if err := body.Validate(); err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	if !IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can start rounds")
+	}
+
+	round, err := StartRound(ctx, db, room, body.StoryId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(201).JSON(round)
+}
+
+// @Summary Vote in a round
+// @Description Records the card of a player, replacing the previous one while the round is hidden. The rounds of an anonymous room keep the card apart from the player, so their votes can't be replaced. The rooms that ask for confidence need one from 1 to 5 with the card; the others refuse it.
+// @Tags Rounds
+// @Param pincode path string true "Pin Code of the Room"
+// @Param n path int true "Number of the Round"
+// @Security PlayerToken
+// @Param body body rounds.VoteRequest true "The vote"
+// @Accept json
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/rounds/{n}/votes [post]
+func vote(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	number, err := roundNumber(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(rounds.VoteRequest)
+	if err := c.BodyParser(body); err != nil {
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
+	}
+
+	if err := body.Validate(); err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	player, err := FindPlayer(ctx, db, room.Id, body.PlayerId)
+	if err != nil {
+		return err
+	}
+	utils.SetPlayer(c, player.Id)
+
+	if !IsPlayer(c, player) {
+		return apierror.New(apierror.Unauthorized, "only the player can cast their vote")
+	}
+
+	if err := Vote(ctx, db, room, number, player.Id, body.Card, body.Confidence); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// @Summary Vote again on a round
+// @Description Opens the next attempt at the story of a revealed round, once its votes were discussed. The votes of the round are kept as the previous attempt, and the new round tells how long the discussion took. Only the facilitator can start re-votes, and only on the latest round.
+// @Tags Rounds
+// @Param pincode path string true "Pin Code of the Room"
+// @Param n path int true "Number of the Round"
+// @Security FacilitatorToken
+// @Produce json
+// @Success 201 {object} rounds.Round
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/rounds/{n}/revote [post]
+func reVote(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	number, err := roundNumber(c)
+	if err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	if !IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can start re-votes")
+	}
+
+	round, err := ReVote(ctx, db, room, number)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(201).JSON(round)
+}
+
+// @Summary Reveal a round
+// @Description Shows the votes of a round. Only the facilitator can reveal rounds. The rounds of an anonymous room only show their cards.
+// @Tags Rounds
+// @Param pincode path string true "Pin Code of the Room"
+// @Param n path int true "Number of the Round"
+// @Security FacilitatorToken
+// @Produce json
+// @Success 200 {object} rounds.Summary
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/rounds/{n}/reveal [post]
+func reveal(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	number, err := roundNumber(c)
+	if err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	if !IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can reveal rounds")
+	}
+
+	summary, err := Reveal(ctx, db, room, number)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(summary)
+}
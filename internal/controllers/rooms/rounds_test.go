@@ -0,0 +1,449 @@
+package rooms
+
+import (
+	"bufio"
+	"bytes"
+	"cloud.google.com/go/firestore"
+	"encoding/json"
+	"fmt"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createRoundRoom(assert *Assert.Assertions) *rooms.Room {
+
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+	roomDoc := db.Collection("rooms").NewDoc()
+	_, err := roomDoc.Set(ctx, map[string]interface{}{
+		"name":      "Room",
+		"pincode":   pinCode,
+		"timestamp": firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	return &rooms.Room{Id: roomDoc.ID, Name: "Room", PinCode: pinCode}
+}
+
+func TestStartRoundNumbersRounds(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	first, err := StartRound(ctx, db, room, "story")
+	assert.NoError(err)
+	assert.Equal(1, first.Number)
+
+	second, err := StartRound(ctx, db, room, "story")
+	assert.NoError(err)
+	assert.Equal(2, second.Number)
+
+	round, err := GetRound(ctx, db, room.Id, 2)
+	assert.NoError(err)
+	assert.Equal("story", round.StoryId)
+	assert.False(round.Revealed)
+}
+
+func TestVoteAndReveal(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	player := db.Collection("rooms").Doc(room.Id).Collection("players").NewDoc()
+	_, err := player.Set(ctx, map[string]interface{}{
+		"name":      "Ana",
+		"timestamp": firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	round, err := StartRound(ctx, db, room, "")
+	assert.NoError(err)
+
+	assert.NoError(Vote(ctx, db, room, round.Number, player.ID, "3", 0))
+	assert.NoError(Vote(ctx, db, room, round.Number, player.ID, "5", 0))
+	assert.Equal(ErrInvalidCard, Vote(ctx, db, room, round.Number, player.ID, "4", 0))
+
+	summary, err := Reveal(ctx, db, room, round.Number)
+	assert.NoError(err)
+	assert.Len(summary.Votes, 1)
+	assert.Equal("Ana", summary.Votes[0].PlayerName)
+	assert.Equal("5", summary.Votes[0].Value)
+
+	assert.Equal(ErrRoundRevealed, Vote(ctx, db, room, round.Number, player.ID, "8", 0))
+	_, err = Reveal(ctx, db, room, round.Number)
+	assert.Equal(ErrRoundRevealed, err)
+}
+
+func TestVoteThatRoundNotExists(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	assert.Equal(ErrRoundNotFound, Vote(ctx, db, room, 42, "player", "5", 0))
+
+	_, err := Reveal(ctx, db, room, 42)
+	assert.Equal(ErrRoundNotFound, err)
+}
+
+func post(path, token string, body interface{}) (*http.Response, error) {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return app.Test(req, 30000)
+}
+
+func TestPlayRoundOverHttp(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	res, err := post(fmt.Sprintf("/rooms/%s/join", room.PinCode), "", rooms.RoomJoinRequest{PlayerName: "Ana"})
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, joined))
+
+	// Start
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds", room.PinCode), room.FacilitatorToken, rounds.RoundNewRequest{})
+	assert.NoError(err)
+	assert.Equal(201, res.StatusCode)
+	round := new(rounds.Round)
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, round))
+	assert.Equal(1, round.Number)
+
+	// Vote
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/votes", room.PinCode), joined.PlayerToken, rounds.VoteRequest{
+		PlayerId: joined.PlayerId,
+		Card:     "5",
+	})
+	assert.NoError(err)
+	assert.Equal(204, res.StatusCode)
+
+	// Reveal
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/reveal", room.PinCode), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	summary := new(rounds.Summary)
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, summary))
+	assert.Equal([]rounds.Vote{{PlayerId: joined.PlayerId, PlayerName: "Ana", Value: "5"}}, summary.Votes)
+}
+
+func TestPlayRoundWithoutTokens(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	res, err := post(fmt.Sprintf("/rooms/%s/join", room.PinCode), "", rooms.RoomJoinRequest{PlayerName: "Ana"})
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, joined))
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds", room.PinCode), joined.PlayerToken, nil)
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	_, err = StartRound(ctx, db, &rooms.Room{Id: room.RoomId, PinCode: room.PinCode}, "")
+	assert.NoError(err)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/votes", room.PinCode), room.FacilitatorToken, rounds.VoteRequest{
+		PlayerId: joined.PlayerId,
+		Card:     "5",
+	})
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/reveal", room.PinCode), joined.PlayerToken, nil)
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+}
+
+func TestPlayAnonymousRound(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{Anonymous: true})
+	assert.NoError(err)
+
+	res, err := post(fmt.Sprintf("/rooms/%s/join", room.PinCode), "", rooms.RoomJoinRequest{PlayerName: "Ana"})
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, joined))
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds", room.PinCode), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(201, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/votes", room.PinCode), joined.PlayerToken, rounds.VoteRequest{
+		PlayerId: joined.PlayerId,
+		Card:     "8",
+	})
+	assert.NoError(err)
+	assert.Equal(204, res.StatusCode)
+
+	// Nothing stored ties the card to the player, even before the reveal
+	snap, err := roundRef(db, room.RoomId, 1).Get(ctx)
+	if !assert.NoError(err) {
+		return
+	}
+	_, err = snap.DataAt("votes")
+	assert.Error(err)
+	voters, _ := snap.DataAt("voters")
+	assert.Equal([]interface{}{joined.PlayerId}, voters)
+	cards, _ := snap.DataAt("cards")
+	assert.Equal([]interface{}{"8"}, cards)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/votes", room.PinCode), joined.PlayerToken, rounds.VoteRequest{
+		PlayerId: joined.PlayerId,
+		Card:     "5",
+	})
+	assert.NoError(err)
+	assert.Equal(409, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/reveal", room.PinCode), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	summary := new(rounds.Summary)
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, summary))
+	assert.True(summary.Anonymous)
+	assert.Empty(summary.Votes)
+	assert.Equal([]string{"8"}, summary.Cards)
+	assert.NotContains(string(bodyResp), joined.PlayerId)
+}
+
+func TestVoteWithConfidence(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{Confidence: true})
+	assert.NoError(err)
+
+	res, err := post(fmt.Sprintf("/rooms/%s/join", room.PinCode), "", rooms.RoomJoinRequest{PlayerName: "Ana"})
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, joined))
+
+	model := &rooms.Room{Id: room.RoomId, PinCode: room.PinCode, Settings: rooms.Settings{Confidence: true}}
+	_, err = StartRound(ctx, db, model, "")
+	assert.NoError(err)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/votes", room.PinCode), joined.PlayerToken, rounds.VoteRequest{
+		PlayerId: joined.PlayerId,
+		Card:     "8",
+	})
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/votes", room.PinCode), joined.PlayerToken, rounds.VoteRequest{
+		PlayerId:   joined.PlayerId,
+		Card:       "8",
+		Confidence: 3,
+	})
+	assert.NoError(err)
+	assert.Equal(204, res.StatusCode)
+
+	summary, err := Reveal(ctx, db, model, 1)
+	assert.NoError(err)
+	assert.Equal(3, summary.Votes[0].Confidence)
+	if assert.NotNil(summary.Confidence) {
+		assert.Equal(3.0, *summary.Confidence.Average)
+	}
+
+	plain, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+	model = &rooms.Room{Id: plain.RoomId, PinCode: plain.PinCode}
+	_, err = StartRound(ctx, db, model, "")
+	assert.NoError(err)
+	assert.Equal(ErrConfidenceUnasked, Vote(ctx, db, model, 1, "player", "5", 4))
+}
+
+func TestReVote(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	res, err := post(fmt.Sprintf("/rooms/%s/join", room.PinCode), "", rooms.RoomJoinRequest{PlayerName: "Ana"})
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, joined))
+
+	model := &rooms.Room{Id: room.RoomId, PinCode: room.PinCode}
+	_, err = StartRound(ctx, db, model, "story")
+	assert.NoError(err)
+	assert.NoError(Vote(ctx, db, model, 1, joined.PlayerId, "3", 0))
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/revote", room.PinCode), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(409, res.StatusCode)
+
+	_, err = Reveal(ctx, db, model, 1)
+	assert.NoError(err)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/revote", room.PinCode), joined.PlayerToken, nil)
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/revote", room.PinCode), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(201, res.StatusCode)
+	round := new(rounds.Round)
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(bodyResp, round))
+	assert.Equal(2, round.Number)
+	assert.Equal("story", round.StoryId)
+	assert.Equal(2, round.Attempt)
+	assert.Greater(round.Discussion, 0.0)
+
+	// The previous attempt keeps its votes
+	previous, err := GetRound(ctx, db, room.RoomId, 1)
+	assert.NoError(err)
+	assert.Equal(map[string]string{joined.PlayerId: "3"}, previous.Votes)
+
+	res, err = post(fmt.Sprintf("/rooms/%s/rounds/1/revote", room.PinCode), room.FacilitatorToken, nil)
+	assert.NoError(err)
+	assert.Equal(409, res.StatusCode)
+}
+
+func TestRoomEvents(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	res, err := http.Get(fmt.Sprintf("%s/rooms/%s/events", baseUrl, room.PinCode))
+	if !assert.NoError(err) {
+		return
+	}
+	defer res.Body.Close()
+	assert.Equal(200, res.StatusCode)
+	assert.Equal("text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	assert.NoError(err)
+	assert.Equal(": connected\n", line)
+	_, _ = reader.ReadString('\n')
+
+	_, err = StartRound(ctx, db, &rooms.Room{Id: room.RoomId, PinCode: room.PinCode}, "")
+	assert.NoError(err)
+
+	lines := make([]string, 0, 3)
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if !assert.NoError(err) {
+			return
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	assert.True(strings.HasPrefix(lines[0], "id: "))
+	assert.Equal("event: round.started", lines[1])
+
+	event := new(events.Event)
+	assert.NoError(json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), event))
+	assert.Equal(room.RoomId, event.RoomId)
+	assert.Equal(room.PinCode, event.PinCode)
+}
+
+func TestRoomEventsOfAPlayer(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	room, err := CreateRoom(ctx, db, "Room", rooms.Settings{})
+	assert.NoError(err)
+
+	res, err := app.Test(joinRequest(room.PinCode, "Ana"), 30000)
+	assert.NoError(err)
+	joined := new(rooms.RoomJoinResponse)
+	assert.NoError(json.NewDecoder(res.Body).Decode(joined))
+
+	// The server notices the stream is gone when it writes the keep-alive
+	keepAlive := KeepAlive
+	KeepAlive = 100 * time.Millisecond
+	defer func() {
+		KeepAlive = keepAlive
+	}()
+
+	url := fmt.Sprintf("%s/rooms/%s/events?player_id=%s", baseUrl, room.PinCode, joined.PlayerId)
+
+	// Only with the token of the player
+	res, err = http.Get(url)
+	if !assert.NoError(err) {
+		return
+	}
+	res.Body.Close()
+	assert.Equal(401, res.StatusCode)
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+joined.PlayerToken)
+	res, err = http.DefaultClient.Do(req)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(200, res.StatusCode)
+
+	assert.Equal([]string{"Ana"}, getPlayerNames(assert, room.PinCode, "?online=true"))
+
+	res.Body.Close()
+	assert.Eventually(func() bool {
+		return len(getPlayerNames(assert, room.PinCode, "?online=false")) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestObserversDontVote(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	player, _, err := db.Collection("rooms").Doc(room.Id).Collection("players").Add(ctx, map[string]interface{}{
+		"name": "Ana",
+		"role": "observer",
+	})
+	assert.NoError(err)
+
+	round, err := StartRound(ctx, db, room, "")
+	assert.NoError(err)
+
+	assert.Equal(ErrObserver, Vote(ctx, db, room, round.Number, player.ID, "5", 0))
+}
+
+func TestWriteShutdownEvent(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	broker := events.NewBroker()
+	stream, _ := broker.Subscribe("room-1")
+	broker.Close()
+
+	w := new(bytes.Buffer)
+	assert.NoError(writeEvent(w, <-stream))
+
+	lines := strings.Split(w.String(), "\n")
+	assert.Equal("retry: 5000", lines[0])
+	assert.True(strings.HasPrefix(lines[1], "id: "))
+	assert.Equal("event: server.shutdown", lines[2])
+	assert.True(strings.HasPrefix(lines[3], "data: {"))
+	assert.Equal("", lines[4])
+}
@@ -0,0 +1,146 @@
+package rooms
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/deadline"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxWait is the longest a long poll is held.
+var MaxWait = 60 * time.Second
+
+// bumpVersion is the update that marks a change of the players, the rounds or
+// the votes of a room. It is written with the change itself, so the version
+// never misses one.
+var bumpVersion = []firestore.Update{{Path: "version", Value: firestore.Increment(1)}}
+
+func roomRef(db *firestore.Client, roomId string) *firestore.DocumentRef {
+	return db.Collection("rooms").Doc(roomId)
+}
+
+// AddPlayer creates the document of a player, bumping the version of its
+// room in the same write. The player starts offline and without a vote, and
+// as a voter unless data has the role: the listings of the players filter on
+// these fields, so every player needs them.
+func AddPlayer(ctx context.Context, db *firestore.Client, player *firestore.DocumentRef, data map[string]interface{}) error {
+	if _, ok := data["role"]; !ok {
+		data["role"] = players.RoleVoter
+	}
+	data["online"] = false
+	data["voted"] = false
+
+	batch := db.Batch()
+	batch.Create(player, data)
+	batch.Update(player.Parent.Parent, bumpVersion)
+
+	_, err := batch.Commit(ctx)
+	return err
+}
+
+// RoomVersion reads the version of a room.
+func RoomVersion(ctx context.Context, db *firestore.Client, roomId string) (int64, error) {
+	snap, err := roomRef(db, roomId).Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version, _ := snap.DataAt("version")
+	n, _ := version.(int64)
+	return n, nil
+}
+
+// ETag is the entity tag of the state of a room at a version.
+func ETag(version int64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// parseETag reads the version of an entity tag, when it is a single one.
+func parseETag(etag string) (int64, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	return version, err == nil
+}
+
+// longPoll reads how long a request waits for a change of the room, and the
+// version the client has, from ?since or else from If-None-Match. It doesn't
+// wait without a version.
+func longPoll(c *fiber.Ctx) (time.Duration, int64, bool, error) {
+	since, known := int64(0), false
+	if value := c.Query("since"); len(value) > 0 {
+		version, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || version < 0 {
+			return 0, 0, false, apierror.Invalid("since", "since must be a version of the room, as in its ETag")
+		}
+		since, known = version, true
+	} else if noneMatch := c.Get(fiber.HeaderIfNoneMatch); len(noneMatch) > 0 {
+		since, known = parseETag(noneMatch)
+	}
+
+	var wait time.Duration
+	if value := c.Query("wait"); len(value) > 0 {
+		var err error
+		wait, err = time.ParseDuration(value)
+		if err != nil || wait < 0 {
+			return 0, 0, false, apierror.Invalid("wait", "wait must be a duration like 30s")
+		}
+		if wait > MaxWait {
+			wait = MaxWait
+		}
+	}
+	if !known {
+		wait = 0
+	}
+
+	return wait, since, known, nil
+}
+
+// waitForChange holds a request until the version of a room passes since, or
+// the wait ends, and returns the version then. It is woken by the events of
+// the room published by this instance; the changes made through the others
+// are seen when the wait ends. The request gets a new deadline afterwards.
+// When the client goes away meanwhile, the wait stops with context.Canceled.
+func waitForChange(c *fiber.Ctx, db *firestore.Client, roomId string, since int64, wait time.Duration) (int64, context.CancelFunc, error) {
+	broker := new(events.Broker)
+	container.Make(&broker)
+
+	// Subscribed before reading the version, so no change is missed
+	stream, unsubscribe := broker.Subscribe(roomId)
+	defer unsubscribe()
+
+	version, err := RoomVersion(utils.Context(c), db, roomId)
+	if err != nil || version > since {
+		return version, func() {}, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	gone, stop := deadline.Watch(c)
+	defer stop()
+
+	// Every event of the room is a change of its state, and the stream is
+	// closed when the server shuts down
+	select {
+	case <-stream:
+	case <-timer.C:
+	case <-gone:
+		return since, func() {}, context.Canceled
+	}
+
+	cancel := deadline.Restart(c)
+	version, err = RoomVersion(utils.Context(c), db, roomId)
+	return version, cancel, err
+}
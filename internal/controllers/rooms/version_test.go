@@ -0,0 +1,107 @@
+package rooms
+
+import (
+	"fmt"
+	Assert "github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func getPlayersRequest(pinCode, query, etag string) *http.Request {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rooms/%s/players%s", pinCode, query), nil)
+	if len(etag) > 0 {
+		req.Header.Set("If-None-Match", etag)
+	}
+	return req
+}
+
+func TestGetPlayersNotModified(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	res, err := app.Test(getPlayersRequest(room.PinCode, "", ""), 30000)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	etag := res.Header.Get("ETag")
+	assert.NotEmpty(etag)
+
+	res, err = app.Test(getPlayersRequest(room.PinCode, "", etag), 30000)
+	assert.NoError(err)
+	assert.Equal(304, res.StatusCode)
+	assert.Equal(etag, res.Header.Get("ETag"))
+
+	// Any change of the room changes its version
+	_, err = StartRound(ctx, db, room, "")
+	assert.NoError(err)
+
+	res, err = app.Test(getPlayersRequest(room.PinCode, "", etag), 30000)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.NotEqual(etag, res.Header.Get("ETag"))
+}
+
+func TestGetPlayersLongPoll(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	version, err := RoomVersion(ctx, db, room.Id)
+	assert.NoError(err)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = StartRound(ctx, db, room, "")
+	}()
+
+	start := time.Now()
+	res, err := app.Test(getPlayersRequest(room.PinCode, fmt.Sprintf("?since=%d&wait=10s", version), ""), 30000)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Equal(ETag(version+1), res.Header.Get("ETag"))
+	assert.Less(int64(time.Since(start)), int64(5*time.Second), "answered when the room changed")
+}
+
+func TestGetPlayersLongPollEnds(t *testing.T) {
+
+	assert := Assert.New(t)
+	room := createRoundRoom(assert)
+
+	res, err := app.Test(getPlayersRequest(room.PinCode, "", ""), 30000)
+	assert.NoError(err)
+	etag := res.Header.Get("ETag")
+
+	start := time.Now()
+	res, err = app.Test(getPlayersRequest(room.PinCode, "?wait=200ms", etag), 30000)
+	assert.NoError(err)
+	assert.Equal(304, res.StatusCode)
+	assert.GreaterOrEqual(int64(time.Since(start)), int64(200*time.Millisecond))
+}
+
+func TestGetPlayersInvalidLongPoll(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for _, query := range []string{"?since=1&wait=soon", "?since=1&wait=-1s", "?since=latest&wait=1s"} {
+		res, err := app.Test(getPlayersRequest("123456", query, ""), 30000)
+		assert.NoError(err)
+		assert.Equal(400, res.StatusCode, query)
+	}
+}
+
+func TestParseETag(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for etag, expected := range map[string]int64{`"42"`: 42, `W/"7"`: 7, ETag(3): 3} {
+		version, ok := parseETag(etag)
+		assert.True(ok, etag)
+		assert.Equal(expected, version)
+	}
+
+	for _, etag := range []string{``, `*`, `42`, `"a"`, `"1", "2"`} {
+		_, ok := parseETag(etag)
+		assert.False(ok, etag)
+	}
+}
@@ -0,0 +1,270 @@
+package slack
+
+import (
+	"bytes"
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
+	roomsModel "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/slack"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+func verify(c *fiber.Ctx) error {
+	// Secret from the "Basic Information" page of the Slack app
+	conf := new(config.Config)
+	container.Make(&conf)
+
+	err := slack.VerifySignature(conf.Slack.SigningSecret, c.Get(slack.HeaderTimestamp), c.Get(slack.HeaderSignature), c.Body(), time.Now())
+	if err != nil {
+		return apierror.Wrap(apierror.Unauthorized, err)
+	}
+	return nil
+}
+
+// @Summary Slack slash command
+// @Description Handles `/poker <story>`: creates a room for the story and posts a message with one button per card.
+// @Tags Slack
+// @Param X-Slack-Signature header string true "Signature of the request"
+// @Param X-Slack-Request-Timestamp header string true "Timestamp of the request"
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} slack.Message
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Router /v1/slack/commands [post]
+func command(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	if err := verify(c); err != nil {
+		return err
+	}
+
+	cmd, err := slack.ParseCommand(c.Body())
+	if err != nil {
+		return apierror.Wrap(apierror.InvalidBody, err)
+	}
+
+	if len(cmd.Text) == 0 {
+		return c.JSON(slack.Ephemeral(fmt.Sprintf("Tell me what to estimate: `%s <story>`", cmd.Command)))
+	}
+
+	// The story names the room, so it follows the rules of their names
+	body := roomsModel.RoomNewRequest{Name: cmd.Text}
+	if err := body.Validate(); err != nil {
+		return c.JSON(slack.Ephemeral(fmt.Sprintf("Sorry, %s.", err)))
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	// The buttons only carry a card, so the rooms of Slack never ask for
+	// confidence
+	room, err := rooms.CreateRoom(ctx, db, body.Name, roomsModel.Settings{})
+	if err != nil {
+		return c.JSON(slack.Ephemeral("Sorry, I couldn't create the room. Please try again."))
+	}
+	utils.SetRoom(c, room.RoomId)
+
+	_, err = db.Collection("rooms").Doc(room.RoomId).Update(ctx, []firestore.Update{
+		{Path: "slack_facilitator", Value: slackUser(cmd.TeamId, cmd.UserId)},
+	})
+	if err != nil {
+		return c.JSON(slack.Ephemeral("Sorry, I couldn't create the room. Please try again."))
+	}
+
+	story, _, err := db.Collection("rooms").Doc(room.RoomId).Collection("stories").Add(ctx, map[string]interface{}{
+		"key":         "",
+		"title":       body.Name,
+		"link":        "",
+		"description": "",
+		"estimate":    "",
+		"timestamp":   firestore.ServerTimestamp,
+	})
+	if err != nil {
+		return c.JSON(slack.Ephemeral("Sorry, I couldn't create the story. Please try again."))
+	}
+
+	round, err := rooms.StartRound(ctx, db, &roomsModel.Room{Id: room.RoomId, Name: body.Name, PinCode: room.PinCode}, story.ID)
+	if err != nil {
+		return c.JSON(slack.Ephemeral("Sorry, I couldn't start the round. Please try again."))
+	}
+
+	return c.JSON(slack.VotingMessage(body.Name, slack.ActionValue{PinCode: room.PinCode, Round: round.Number}, rounds.DefaultDeck, nil))
+}
+
+// @Summary Slack interactive messages
+// @Description Handles the buttons of the voting message: a card records the vote of the user, "Reveal" shows the results.
+// @Description Only the user who created the room can reveal, and only the rooms created in Slack are played there.
+// @Description The message is updated through the response_url of the interaction.
+// @Tags Slack
+// @Param X-Slack-Signature header string true "Signature of the request"
+// @Param X-Slack-Request-Timestamp header string true "Timestamp of the request"
+// @Accept x-www-form-urlencoded
+// @Success 200
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Router /v1/slack/interactions [post]
+func interaction(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	if err := verify(c); err != nil {
+		return err
+	}
+
+	payload, err := slack.ParseInteraction(c.Body())
+	if err != nil {
+		return apierror.Wrap(apierror.InvalidBody, err)
+	}
+
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return c.SendStatus(200)
+	}
+
+	action := payload.Actions[0]
+	value, err := slack.ParseActionValue(action.Value)
+	if err != nil {
+		return apierror.Wrap(apierror.InvalidBody, err)
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := rooms.FindRoom(ctx, db, value.PinCode)
+	if err != nil {
+		go respond(payload.ResponseUrl, slack.Ephemeral("This room doesn't exist anymore."))
+		return c.SendStatus(200)
+	}
+	utils.SetRoom(c, room.Id)
+
+	if len(room.SlackFacilitator) == 0 {
+		go respond(payload.ResponseUrl, slack.Ephemeral("This room is played in the app, not in Slack."))
+		return c.SendStatus(200)
+	}
+
+	switch {
+	case strings.HasPrefix(action.ActionId, slack.ActionVotePrefix):
+		playerId, err := slackPlayer(ctx, db, room.Id, payload)
+		if err == nil {
+			utils.SetPlayer(c, playerId)
+			err = rooms.Vote(ctx, db, room, value.Round, playerId, value.Card, 0)
+		}
+		if err != nil {
+			go respond(payload.ResponseUrl, slack.Ephemeral(replyFor(err)))
+			return c.SendStatus(200)
+		}
+
+		round, err := rooms.GetRound(ctx, db, room.Id, value.Round)
+		if err != nil {
+			return c.SendStatus(200)
+		}
+		summary, err := rooms.Summarize(ctx, db, room.Id, *round)
+		if err != nil {
+			return c.SendStatus(200)
+		}
+
+		voters := make([]string, len(summary.Votes))
+		for i, vote := range summary.Votes {
+			voters[i] = vote.PlayerName
+		}
+		go respond(payload.ResponseUrl, slack.VotingMessage(summary.StoryTitle, value, rounds.DefaultDeck, voters))
+
+	case action.ActionId == slack.ActionReveal:
+		if slackUser(payload.Team.Id, payload.User.Id) != room.SlackFacilitator {
+			go respond(payload.ResponseUrl, slack.Ephemeral("Only the person who started this estimate can reveal it."))
+			return c.SendStatus(200)
+		}
+
+		summary, err := rooms.Reveal(ctx, db, room, value.Round)
+		if err != nil {
+			go respond(payload.ResponseUrl, slack.Ephemeral(replyFor(err)))
+			return c.SendStatus(200)
+		}
+		go respond(payload.ResponseUrl, slack.ResultsMessage(room.PinCode, *summary))
+	}
+
+	return c.SendStatus(200)
+}
+
+func replyFor(err error) string {
+	switch err {
+	case rooms.ErrRoundRevealed:
+		return "This round was already revealed."
+	case rooms.ErrRoundNotFound:
+		return "This round doesn't exist anymore."
+	case rooms.ErrInvalidCard:
+		return "This card is not part of the deck."
+	}
+	return "Sorry, something went wrong. Please try again."
+}
+
+// slackUser identifies a Slack user across the workspaces, as the ID of
+// their player.
+func slackUser(teamId, userId string) string {
+	return fmt.Sprintf("slack-%s-%s", teamId, userId)
+}
+
+// slackPlayer returns the player that represents the Slack user in the room,
+// joining the room on the first vote.
+func slackPlayer(ctx context.Context, db *firestore.Client, roomId string, payload *slack.Interaction) (string, error) {
+	playerId := slackUser(payload.Team.Id, payload.User.Id)
+
+	player := db.Collection("rooms").Doc(roomId).Collection("players").Doc(playerId)
+	err := rooms.AddPlayer(ctx, db, player, map[string]interface{}{
+		"name":      payload.DisplayName(),
+		"timestamp": firestore.ServerTimestamp,
+	})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return "", err
+	}
+	if err == nil {
+		metrics.PlayersJoined.Inc()
+	}
+
+	return playerId, nil
+}
+
+// respond posts a message to the response_url of a command or interaction.
+// Slack keeps these URLs valid for 30 minutes.
+func respond(url string, message slack.Message) {
+	if len(url) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	res, err := httpClient.Post(url, fiber.MIMEApplicationJSON, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	_ = res.Body.Close()
+}
+
+// Registrar endpoints
+func Register(router fiber.Router) {
+
+	group := router.Group("/slack")
+
+	group.Post("commands", command)
+	group.Post("interactions", interaction)
+}
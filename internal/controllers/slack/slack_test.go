@@ -0,0 +1,271 @@
+package slack
+
+import (
+	"bytes"
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	roomsModel "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/slack"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"golang.org/x/net/nettest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+var app *fiber.App
+var db *firestore.Client
+var ctx context.Context
+
+func TestMain(m *testing.M) {
+
+	ctx = context.Background()
+	conf := config.Default()
+	conf.Slack.SigningSecret = secret
+
+	_ = di.SetupDependencies(conf)
+	container.Make(&db)
+
+	app = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	Register(app)
+
+	listener, _ := nettest.NewLocalListener("tcp")
+	go func() {
+		_ = app.Listener(listener)
+	}()
+
+	m.Run()
+
+	defer func() {
+		db.Close()
+		_ = app.Shutdown()
+	}()
+}
+
+func signedRequest(path string, form url.Values, key string) (*http.Response, error) {
+	body := []byte(form.Encode())
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, _ := http.NewRequest("POST", path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(slack.HeaderTimestamp, timestamp)
+	req.Header.Set(slack.HeaderSignature, slack.Sign(key, timestamp, body))
+	return app.Test(req, 30000)
+}
+
+func interact(assert *Assert.Assertions, responseUrl, userId string, action slack.Action) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"team":         map[string]string{"id": "T1"},
+		"user":         map[string]string{"id": userId, "username": userId},
+		"actions":      []slack.Action{action},
+		"response_url": responseUrl,
+	})
+
+	res, err := signedRequest("/slack/interactions", url.Values{"payload": {string(payload)}}, secret)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+}
+
+func TestSlashCommandVoteAndReveal(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	messages := make(chan slack.Message, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := slack.Message{}
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		messages <- message
+	}))
+	defer receiver.Close()
+
+	next := func() slack.Message {
+		select {
+		case message := <-messages:
+			return message
+		case <-time.After(10 * time.Second):
+			assert.Fail("no message was posted to the response_url")
+			return slack.Message{}
+		}
+	}
+
+	// Create the room
+	res, err := signedRequest("/slack/commands", url.Values{
+		"team_id":      {"T1"},
+		"user_id":      {"U1"},
+		"command":      {"/poker"},
+		"text":         {"Login page"},
+		"response_url": {receiver.URL},
+	}, secret)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	message := slack.Message{}
+	assert.NoError(json.Unmarshal(bodyResp, &message))
+	assert.Equal("in_channel", message.ResponseType)
+	assert.Equal("Planning poker: Login page", message.Text)
+
+	card := message.Blocks[1].Elements[3].(map[string]interface{})
+	reveal := message.Blocks[2].Elements[0].(map[string]interface{})
+
+	value, err := slack.ParseActionValue(card["value"].(string))
+	assert.NoError(err)
+	assert.Equal(1, value.Round)
+
+	room, _ := db.Collection("rooms").Where("pincode", "==", value.PinCode).Documents(ctx).GetAll()
+	assert.Len(room, 1)
+	assert.Equal("Login page", room[0].Data()["name"])
+
+	// Vote
+	interact(assert, receiver.URL, "U1", slack.Action{ActionId: card["action_id"].(string), Value: card["value"].(string)})
+	updated := next()
+	assert.Equal("1 voted: U1", updated.Blocks[3].Elements[0].(map[string]interface{})["text"])
+
+	assert.Equal("slack-T1-U1", room[0].Data()["slack_facilitator"])
+
+	// Only the user who created the room reveals it
+	interact(assert, receiver.URL, "U2", slack.Action{ActionId: reveal["action_id"].(string), Value: reveal["value"].(string)})
+	denied := next()
+	assert.Equal("ephemeral", denied.ResponseType)
+	assert.Equal("Only the person who started this estimate can reveal it.", denied.Text)
+
+	// Reveal
+	interact(assert, receiver.URL, "U1", slack.Action{ActionId: reveal["action_id"].(string), Value: reveal["value"].(string)})
+	results := next()
+	assert.Equal("Results for Login page", results.Text)
+
+	// Voting after the reveal is refused
+	interact(assert, receiver.URL, "U2", slack.Action{ActionId: card["action_id"].(string), Value: card["value"].(string)})
+	refused := next()
+	assert.Equal("ephemeral", refused.ResponseType)
+	assert.Equal("This round was already revealed.", refused.Text)
+}
+
+func TestVoteInAppRoom(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	messages := make(chan slack.Message, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := slack.Message{}
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		messages <- message
+	}))
+	defer receiver.Close()
+
+	// E.g. a room asking for the confidence, which the buttons can't send
+	room, err := rooms.CreateRoom(ctx, db, "Confident", roomsModel.Settings{Confidence: true})
+	if !assert.NoError(err) {
+		return
+	}
+	round, err := rooms.StartRound(ctx, db, &roomsModel.Room{Id: room.RoomId, PinCode: room.PinCode, Settings: roomsModel.Settings{Confidence: true}}, "")
+	if !assert.NoError(err) {
+		return
+	}
+
+	value := slack.ActionValue{PinCode: room.PinCode, Round: round.Number, Card: "5"}
+	interact(assert, receiver.URL, "U1", slack.Action{ActionId: slack.ActionVotePrefix + "5", Value: value.String()})
+
+	select {
+	case message := <-messages:
+		assert.Equal("ephemeral", message.ResponseType)
+		assert.Equal("This room is played in the app, not in Slack.", message.Text)
+	case <-time.After(10 * time.Second):
+		assert.Fail("no message was posted to the response_url")
+	}
+}
+
+func TestSlashCommandWithoutStory(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	res, err := signedRequest("/slack/commands", url.Values{
+		"user_id": {"U1"},
+		"command": {"/poker"},
+		"text":    {""},
+	}, secret)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	message := slack.Message{}
+	assert.NoError(json.Unmarshal(bodyResp, &message))
+	assert.Equal("ephemeral", message.ResponseType)
+	assert.Equal("Tell me what to estimate: `/poker <story>`", message.Text)
+}
+
+func TestSlashCommandInvalidStory(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	res, err := signedRequest("/slack/commands", url.Values{
+		"user_id": {"U1"},
+		"command": {"/poker"},
+		"text":    {strings.Repeat("a", 101)},
+	}, secret)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	message := slack.Message{}
+	assert.NoError(json.Unmarshal(bodyResp, &message))
+	assert.Equal("ephemeral", message.ResponseType)
+	assert.Equal("Sorry, the name of the room must have at most 100 characters.", message.Text)
+}
+
+func TestSlashCommandInvalidSignature(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	res, err := signedRequest("/slack/commands", url.Values{
+		"user_id": {"U1"},
+		"command": {"/poker"},
+		"text":    {"Login page"},
+	}, "wrong secret")
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+}
+
+func TestInteractionInvalidSignature(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	res, err := signedRequest("/slack/interactions", url.Values{"payload": {"{}"}}, "wrong secret")
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+}
+
+func TestRegisterRoutes(t *testing.T) {
+
+	_ = Assert.New(t)
+
+	router := new(test.MockRouter)
+	router.On("Group", "/slack", mock.Anything).Return(router)
+	router.On("Post", "commands", mock.Anything).Return(router)
+	router.On("Post", "interactions", mock.Anything).Return(router)
+
+	Register(router)
+
+	router.AssertExpectations(t)
+
+}
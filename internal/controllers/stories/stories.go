@@ -0,0 +1,363 @@
+package stories
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/backlog"
+	roomsController "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/pagination"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Firestore doesn't accept more than 500 writes in a batch
+const batchSize = 500
+
+// @Summary Import stories into a room
+// @Description Bulk-creates stories from a CSV file (title, key, link, description) or from a Jira/GitHub issues JSON export.
+// @Description Stories are identified by their key, so uploading the same file again updates them instead of creating duplicates.
+// @Tags Stories
+// @Param pincode path string true "Pin Code of the Room"
+// @Param format query string false "Format of the file, detected from its content when omitted" Enums(csv, jira, github)
+// @Param title_column query string false "CSV column holding the title" default(title)
+// @Param key_column query string false "CSV column holding the key" default(key)
+// @Param link_column query string false "CSV column holding the link" default(link)
+// @Param description_column query string false "CSV column holding the description" default(description)
+// @Param file formData file false "File to import, when sent as multipart/form-data"
+// @Security FacilitatorToken
+// @Accept mpfd
+// @Accept text/csv
+// @Accept json
+// @Produce json
+// @Success 200 {object} stories.StoryImportResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/stories/import [post]
+func importStories(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	pinCode := c.Params("pincode")
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := roomsController.FindRoom(ctx, db, pinCode)
+	if err != nil {
+		return err
+	}
+
+	utils.SetRoom(c, room.Id)
+
+	if !roomsController.IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can import stories")
+	}
+
+	mapping := new(backlog.Mapping)
+	if err := c.QueryParser(mapping); err != nil {
+		return apierror.Wrap(apierror.ValidationFailed, err)
+	}
+
+	data, err := uploadedFile(c)
+	if err != nil {
+		return apierror.Wrap(apierror.InvalidBody, err)
+	}
+
+	format := backlog.Detect(data)
+	if value := c.Query("format"); len(value) > 0 {
+		if format, err = backlog.ParseFormat(value); err != nil {
+			return apierror.Invalid("format", err.Error())
+		}
+	}
+
+	rows, err := backlog.Parse(format, data, *mapping)
+	if err != nil {
+		return apierror.Wrap(apierror.InvalidBody, err)
+	}
+
+	valid, errs := backlog.ValidateAll(rows)
+
+	created, updated, err := saveStories(ctx, db, db.Collection("rooms").Doc(room.Id).Collection("stories"), valid)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(stories.StoryImportResponse{
+		Created: created,
+		Updated: updated,
+		Errors:  errs,
+	})
+}
+
+// uploadedFile reads the file either from the "file" field of a multipart form
+// or, for any other content type, from the raw body.
+func uploadedFile(c *fiber.Ctx) ([]byte, error) {
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("the file to import is required")
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return ioutil.ReadAll(file)
+	}
+
+	if len(c.Body()) == 0 {
+		return nil, errors.New("the file to import is required")
+	}
+
+	return c.Body(), nil
+}
+
+// storyId derives the document ID from the external key, which is what makes
+// importing the same story twice update it instead of adding a duplicate.
+func storyId(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:20]
+}
+
+func saveStories(ctx context.Context, db *firestore.Client, col *firestore.CollectionRef, rows []backlog.Row) (int, int, error) {
+	created, updated := 0, 0
+
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+
+		refs := make([]*firestore.DocumentRef, len(chunk))
+		keyed := make([]*firestore.DocumentRef, 0, len(chunk))
+		for i, row := range chunk {
+			if len(row.Key) == 0 {
+				refs[i] = col.NewDoc()
+				continue
+			}
+			refs[i] = col.Doc(storyId(row.Key))
+			keyed = append(keyed, refs[i])
+		}
+
+		exists := make(map[string]bool)
+		if len(keyed) > 0 {
+			snaps, err := db.GetAll(ctx, keyed)
+			if err != nil {
+				return 0, 0, err
+			}
+			for _, snap := range snaps {
+				exists[snap.Ref.ID] = snap.Exists()
+			}
+		}
+
+		batch := db.Batch()
+		for i, row := range chunk {
+			if exists[refs[i].ID] {
+				batch.Set(refs[i], map[string]interface{}{
+					"key":         row.Key,
+					"title":       row.Title,
+					"link":        row.Link,
+					"description": row.Description,
+				}, firestore.MergeAll)
+				updated++
+				continue
+			}
+			batch.Create(refs[i], map[string]interface{}{
+				"key":         row.Key,
+				"title":       row.Title,
+				"link":        row.Link,
+				"description": row.Description,
+				"estimate":    "",
+				"timestamp":   firestore.ServerTimestamp,
+			})
+			created++
+		}
+
+		if _, err := batch.Commit(ctx); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return created, updated, nil
+}
+
+// The sorts of the stories, and the fields they order by
+var storySorts = map[string]string{
+	"created_at": "timestamp",
+	"key":        "key",
+	"title":      "title",
+}
+
+// @Summary List the stories of a room
+// @Description The stories come in pages, linked by the Link header with rel="next" until the last one.
+// @Description With estimated=true, a page can hold fewer stories than the limit, even none, before the last one.
+// @Tags Stories
+// @Param pincode path string true "Pin Code of the Room"
+// @Param limit query int false "Stories per page, up to 500" default(100)
+// @Param cursor query string false "Where the page starts, from the Link of the previous one"
+// @Param sort query string false "Order of the stories" Enums(created_at, key, title) default(created_at)
+// @Param order query string false "Direction of the order" Enums(asc, desc) default(asc)
+// @Param estimated query bool false "Only the stories with an estimate, or without one"
+// @Produce json
+// @Success 200 {array} stories.Story
+// @Header 200 {string} Link "The next page"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/stories [get]
+func listStories(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	page, err := pagination.Parse(c, storySorts, "created_at")
+	if err != nil {
+		return err
+	}
+
+	var estimated *bool
+	if value := c.Query("estimated"); len(value) > 0 {
+		value, err := strconv.ParseBool(value)
+		if err != nil {
+			return apierror.Invalid("estimated", "estimated must be true or false")
+		}
+		estimated = &value
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := roomsController.FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	col := db.Collection("rooms").Doc(room.Id).Collection("stories")
+	query := col.Query
+	var keep func(*firestore.DocumentSnapshot) bool
+	switch {
+	case estimated == nil:
+	case !*estimated:
+		query = query.Where("estimate", "==", "")
+	default:
+		// Firestore would have to order by the estimate to tell the stories
+		// that have one
+		keep = func(snap *firestore.DocumentSnapshot) bool {
+			estimate, _ := snap.DataAt("estimate")
+			return estimate != nil && estimate != ""
+		}
+	}
+
+	snaps, next, err := page.List(ctx, col, query, keep)
+	if err != nil {
+		return err
+	}
+
+	list := make([]stories.Story, len(snaps))
+	for i, snap := range snaps {
+		if err := snap.DataTo(&list[i]); err != nil {
+			return err
+		}
+		list[i].Id = snap.Ref.ID
+	}
+
+	pagination.Link(c, next)
+	return c.JSON(list)
+}
+
+var ErrStoryNotFound = apierror.New(apierror.NotFound, "story not found")
+
+// @Summary Estimate a story
+// @Description Sets the final estimate of a story, replacing the previous one. Only the facilitator can estimate stories.
+// @Tags Stories
+// @Param pincode path string true "Pin Code of the Room"
+// @Param id path string true "ID of the Story"
+// @Param body body stories.StoryEstimateRequest true "The estimate"
+// @Security FacilitatorToken
+// @Accept json
+// @Produce json
+// @Success 200 {object} stories.Story
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/stories/{id}/estimate [put]
+func estimateStory(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	body := new(stories.StoryEstimateRequest)
+	if err := c.BodyParser(body); err != nil {
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
+	}
+
+	if err := body.Validate(); err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := roomsController.FindRoom(ctx, db, c.Params("pincode"))
+	if err != nil {
+		return err
+	}
+	utils.SetRoom(c, room.Id)
+
+	if !roomsController.IsFacilitator(c, room) {
+		return apierror.New(apierror.Unauthorized, "only the facilitator of the room can estimate stories")
+	}
+
+	ref := db.Collection("rooms").Doc(room.Id).Collection("stories").Doc(c.Params("id"))
+	story := new(stories.Story)
+	err = db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrStoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := snap.DataTo(story); err != nil {
+			return err
+		}
+		story.Id = snap.Ref.ID
+		story.Estimate = body.Estimate
+
+		return tx.Update(ref, []firestore.Update{{Path: "estimate", Value: body.Estimate}})
+	})
+	if err != nil {
+		return err
+	}
+
+	roomsController.Publish(ctx, room.Id, room.PinCode, webhooks.EventStoryEstimated, story)
+
+	return c.JSON(story)
+}
+
+// Registrar endpoints
+func Register(router fiber.Router) {
+
+	story := router.Group("/rooms/:pincode/stories")
+
+	story.Get("", listStories)
+	story.Post("import", importStories)
+	story.Put(":id/estimate", estimateStory)
+}
@@ -0,0 +1,451 @@
+package stories
+
+import (
+	"bytes"
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	webhooksDispatcher "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"golang.org/x/net/nettest"
+	"io/ioutil"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var app *fiber.App
+var db *firestore.Client
+var ctx context.Context
+
+func TestMain(m *testing.M) {
+
+	ctx = context.Background()
+
+	// The webhooks of the tests are delivered to the local host
+	conf := config.Default()
+	conf.Webhooks.AllowPrivate = true
+	_ = di.SetupDependencies(conf)
+	container.Make(&db)
+
+	app = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	Register(app)
+
+	listener, _ := nettest.NewLocalListener("tcp")
+	go func() {
+		_ = app.Listener(listener)
+	}()
+
+	m.Run()
+
+	defer func() {
+		db.Close()
+		_ = app.Shutdown()
+	}()
+}
+
+func createRoom(assert *Assert.Assertions) (string, *firestore.DocumentRef, string) {
+
+	token, _ := utils.NewToken()
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+
+	roomDoc := db.Collection("rooms").NewDoc()
+	_, err := roomDoc.Set(ctx, map[string]interface{}{
+		"name":              "Room",
+		"pincode":           pinCode,
+		"facilitator_token": utils.HashToken(token),
+		"timestamp":         firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	return pinCode, roomDoc, token
+}
+
+func importFile(pinCode, token, contentType string, body []byte, query string) (*http.Response, error) {
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/rooms/%s/stories/import%s", pinCode, query), bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return app.Test(req, 30000)
+}
+
+func TestImportStoriesFromCsv(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	data := []byte("title,key,link\nFirst story,PKR-1,https://example.com/PKR-1\n,PKR-2,\nThird story,,\n")
+
+	res, err := importFile(pinCode, token, "text/csv", data, "")
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	result := new(stories.StoryImportResponse)
+	assert.NoError(json.Unmarshal(bodyResp, result))
+
+	assert.Equal(2, result.Created)
+	assert.Equal(0, result.Updated)
+	assert.Equal([]stories.StoryImportError{
+		{Row: 3, Field: "title", Message: "the title of the story is required"},
+	}, result.Errors)
+
+	snap, err := roomDoc.Collection("stories").Doc(storyId("PKR-1")).Get(ctx)
+	assert.NoError(err)
+	assert.Equal("First story", snap.Data()["title"])
+	assert.Equal("https://example.com/PKR-1", snap.Data()["link"])
+}
+
+func TestImportStoriesTwiceDoesNotDuplicate(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	res, err := importFile(pinCode, token, "text/csv", []byte("title,key\nFirst story,PKR-1\n"), "")
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	res, err = importFile(pinCode, token, "text/csv", []byte("title,key\nRenamed story,PKR-1\n"), "")
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	result := new(stories.StoryImportResponse)
+	assert.NoError(json.Unmarshal(bodyResp, result))
+	assert.Equal(0, result.Created)
+	assert.Equal(1, result.Updated)
+
+	snaps, err := roomDoc.Collection("stories").Documents(ctx).GetAll()
+	assert.NoError(err)
+	assert.Len(snaps, 1)
+	assert.Equal("Renamed story", snaps[0].Data()["title"])
+}
+
+func TestImportStoriesFromMultipartGitHubExport(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, _ := form.CreateFormFile("file", "issues.json")
+	_, _ = part.Write([]byte(`[{"number":7,"title":"From GitHub","html_url":"https://github.com/acme/app/issues/7"}]`))
+	_ = form.Close()
+
+	res, err := importFile(pinCode, token, form.FormDataContentType(), body.Bytes(), "")
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	snap, err := roomDoc.Collection("stories").Doc(storyId("acme/app#7")).Get(ctx)
+	assert.NoError(err)
+	assert.Equal("From GitHub", snap.Data()["title"])
+}
+
+func TestImportStoriesWithoutToken(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, _ := createRoom(assert)
+
+	res, err := importFile(pinCode, "", "text/csv", []byte("title\nStory\n"), "")
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      401,
+		ErrorCode: "unauthorized",
+		Message:   "only the facilitator of the room can import stories",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestImportStoriesInvalidFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	res, err := importFile(pinCode, token, "text/csv", []byte("title\nStory\n"), "?format=xlsx")
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the format must be one of csv, jira or github",
+		Fields: []models.FieldError{
+			{Field: "format", Message: "the format must be one of csv, jira or github"},
+		},
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestImportStoriesEmptyFile(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	res, err := importFile(pinCode, token, "text/csv", nil, "")
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      400,
+		ErrorCode: "invalid_body",
+		Message:   "the file to import is required",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestImportStoriesThatRoomNotExists(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+
+	res, err := importFile(pinCode, "token", "text/csv", []byte("title\nStory\n"), "")
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func getStories(url string) ([]stories.Story, string, error) {
+	req, _ := http.NewRequest("GET", url, nil)
+	res, err := app.Test(req, 30000)
+	if err != nil {
+		return nil, "", err
+	}
+	if res.StatusCode != 200 {
+		return nil, "", fmt.Errorf("answered %d", res.StatusCode)
+	}
+
+	list := make([]stories.Story, 0)
+	err = json.NewDecoder(res.Body).Decode(&list)
+	return list, res.Header.Get("Link"), err
+}
+
+func TestListStoriesInPages(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	csv := "title,key\nLogin,APP-3\nSignup,APP-1\nLogout,APP-2\n"
+	res, err := importFile(pinCode, token, "text/csv", []byte(csv), "")
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	keys := make([]string, 0)
+	url := fmt.Sprintf("/rooms/%s/stories?sort=key&limit=2", pinCode)
+	for pages := 0; len(url) > 0; pages++ {
+		assert.Less(pages, 2)
+
+		list, link, err := getStories(url)
+		if !assert.NoError(err) {
+			return
+		}
+		for _, story := range list {
+			keys = append(keys, story.Key)
+		}
+
+		url = ""
+		if len(link) > 0 {
+			url = link[1:strings.Index(link, ">")]
+		}
+	}
+	assert.Equal([]string{"APP-1", "APP-2", "APP-3"}, keys)
+}
+
+func TestListStoriesEstimated(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	csv := "title,key\nLogin,APP-1\nSignup,APP-2\n"
+	_, err := importFile(pinCode, token, "text/csv", []byte(csv), "")
+	assert.NoError(err)
+
+	_, err = roomDoc.Collection("stories").Doc(storyId("APP-2")).Update(ctx, []firestore.Update{{Path: "estimate", Value: "5"}})
+	assert.NoError(err)
+
+	list, _, err := getStories(fmt.Sprintf("/rooms/%s/stories?estimated=true", pinCode))
+	assert.NoError(err)
+	if assert.Len(list, 1) {
+		assert.Equal("APP-2", list[0].Key)
+		assert.Equal(storyId("APP-2"), list[0].Id)
+	}
+
+	list, _, err = getStories(fmt.Sprintf("/rooms/%s/stories?estimated=false", pinCode))
+	assert.NoError(err)
+	if assert.Len(list, 1) {
+		assert.Equal("APP-1", list[0].Key)
+	}
+}
+
+func TestListStoriesEstimatedInPages(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	csv := "title,key\nLogin,APP-1\nSignup,APP-2\nLogout,APP-3\n"
+	_, err := importFile(pinCode, token, "text/csv", []byte(csv), "")
+	assert.NoError(err)
+
+	_, err = roomDoc.Collection("stories").Doc(storyId("APP-3")).Update(ctx, []firestore.Update{{Path: "estimate", Value: "5"}})
+	assert.NoError(err)
+
+	// A page only reads as many stories as the limit, so the pages before
+	// the estimated story are empty
+	keys := make([]string, 0)
+	url := fmt.Sprintf("/rooms/%s/stories?sort=key&limit=1&estimated=true", pinCode)
+	for pages := 0; len(url) > 0; pages++ {
+		assert.Less(pages, 3)
+
+		list, link, err := getStories(url)
+		if !assert.NoError(err) {
+			return
+		}
+		for _, story := range list {
+			keys = append(keys, story.Key)
+		}
+
+		url = ""
+		if len(link) > 0 {
+			url = link[1:strings.Index(link, ">")]
+		}
+	}
+	assert.Equal([]string{"APP-3"}, keys)
+}
+
+func TestListStoriesInvalidQuery(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for _, query := range []string{"?sort=estimate", "?limit=1000", "?estimated=maybe"} {
+		req, _ := http.NewRequest("GET", "/rooms/123456/stories"+query, nil)
+		res, err := app.Test(req, 30000)
+		assert.NoError(err)
+		assert.Equal(400, res.StatusCode, query)
+	}
+}
+
+func estimate(pinCode, id, token string, body interface{}) (*http.Response, error) {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/rooms/%s/stories/%s/estimate", pinCode, id), bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return app.Test(req, 30000)
+}
+
+func TestEstimateStory(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	csv := "title,key\nLogin,APP-1\n"
+	_, err := importFile(pinCode, token, "text/csv", []byte(csv), "")
+	assert.NoError(err)
+
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	_, _, err = roomDoc.Collection("webhooks").Add(ctx, map[string]interface{}{
+		"url":       receiver.URL,
+		"events":    []string{webhooks.EventStoryEstimated},
+		"secret":    "secret",
+		"timestamp": firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	res, err := estimate(pinCode, storyId("APP-1"), token, stories.StoryEstimateRequest{Estimate: "5"})
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	story := new(stories.Story)
+	assert.NoError(json.NewDecoder(res.Body).Decode(story))
+	assert.Equal(storyId("APP-1"), story.Id)
+	assert.Equal("APP-1", story.Key)
+	assert.Equal("5", story.Estimate)
+
+	snap, err := roomDoc.Collection("stories").Doc(storyId("APP-1")).Get(ctx)
+	assert.NoError(err)
+	assert.Equal("5", snap.Data()["estimate"])
+
+	select {
+	case body := <-bodies:
+		payload := new(webhooksDispatcher.Payload)
+		assert.NoError(json.Unmarshal(body, payload))
+		assert.Equal(webhooks.EventStoryEstimated, payload.Event)
+		data, _ := json.Marshal(payload.Data)
+		estimated := new(stories.Story)
+		assert.NoError(json.Unmarshal(data, estimated))
+		assert.Equal("5", estimated.Estimate)
+	case <-time.After(10 * time.Second):
+		assert.Fail("the webhook was not called")
+	}
+}
+
+func TestEstimateStoryInvalid(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	res, err := estimate(pinCode, "story", "", stories.StoryEstimateRequest{Estimate: "5"})
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	res, err = estimate(pinCode, "story", token, stories.StoryEstimateRequest{Estimate: "5"})
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+
+	res, err = estimate(pinCode, "story", token, stories.StoryEstimateRequest{})
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+}
+
+func TestRegisterRoutes(t *testing.T) {
+
+	_ = Assert.New(t)
+
+	router := new(test.MockRouter)
+	router.On("Group", "/rooms/:pincode/stories", mock.Anything).Return(router)
+	router.On("Get", "", mock.Anything).Return(router)
+	router.On("Post", "import", mock.Anything).Return(router)
+	router.On("Put", ":id/estimate", mock.Anything).Return(router)
+
+	Register(router)
+
+	router.AssertExpectations(t)
+
+}
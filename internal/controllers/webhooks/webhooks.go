@@ -0,0 +1,232 @@
+package webhooks
+
+import (
+	"cloud.google.com/go/firestore"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	roomsController "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	webhooksDispatcher "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"time"
+)
+
+// How many deliveries the log endpoint returns
+const deliveriesLimit = 100
+
+// facilitatorRoom finds the room of the request and checks that it was made by
+// its facilitator.
+func facilitatorRoom(c *fiber.Ctx, db *firestore.Client) (*rooms.Room, error) {
+	room, err := roomsController.FindRoom(utils.Context(c), db, c.Params("pincode"))
+	if err != nil {
+		return nil, err
+	}
+
+	utils.SetRoom(c, room.Id)
+
+	if !roomsController.IsFacilitator(c, room) {
+		return nil, apierror.New(apierror.Unauthorized, "only the facilitator of the room can manage webhooks")
+	}
+
+	return room, nil
+}
+
+// @Summary Register a webhook in a room
+// @Description The events are POSTed as JSON, signed with the returned secret in the X-ScrumPoker-Signature header. The url must not point to a private address.
+// @Tags Webhooks
+// @Param pincode path string true "Pin Code of the Room"
+// @Param body body webhooks.WebhookNewRequest true "Webhook to register"
+// @Security FacilitatorToken
+// @Accept json
+// @Produce json
+// @Success 200 {object} webhooks.WebhookNewResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/webhooks [post]
+func newWebhook(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	body := new(webhooks.WebhookNewRequest)
+	if err := c.BodyParser(body); err != nil {
+		return apierror.New(apierror.InvalidBody, "the body of the request must be JSON")
+	}
+
+	if err := body.Validate(); err != nil {
+		return err
+	}
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := facilitatorRoom(c, db)
+	if err != nil {
+		return err
+	}
+
+	// Only resolve the url for the facilitator
+	conf := new(config.Config)
+	container.Make(&conf)
+
+	if !conf.Webhooks.AllowPrivate {
+		if err := webhooksDispatcher.CheckUrl(ctx, body.Url); err != nil {
+			return apierror.Invalid("url", "the url of the webhook must not point to a private address")
+		}
+	}
+
+	secret, err := utils.NewToken()
+	if err != nil {
+		return err
+	}
+
+	events := body.Events
+	if events == nil {
+		events = make([]string, 0)
+	}
+
+	now := time.Now().UTC()
+	doc, _, err := db.Collection("rooms").Doc(room.Id).Collection("webhooks").Add(ctx, map[string]interface{}{
+		"url":       body.Url,
+		"events":    events,
+		"secret":    secret,
+		"timestamp": now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(webhooks.WebhookNewResponse{
+		Webhook: webhooks.Webhook{
+			Id:        doc.ID,
+			Url:       body.Url,
+			Events:    events,
+			CreatedAt: now,
+		},
+		Secret: secret,
+	})
+}
+
+// @Summary Get the webhooks of a room
+// @Tags Webhooks
+// @Param pincode path string true "Pin Code of the Room"
+// @Security FacilitatorToken
+// @Produce json
+// @Success 200 {array} webhooks.Webhook
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/webhooks [get]
+func getWebhooks(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := facilitatorRoom(c, db)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := db.Collection("rooms").Doc(room.Id).Collection("webhooks").OrderBy("timestamp", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	hooks := make([]webhooks.Webhook, len(snaps))
+	for i, snap := range snaps {
+		if err := snap.DataTo(&hooks[i]); err != nil {
+			return err
+		}
+		hooks[i].Id = snap.Ref.ID
+	}
+
+	return c.JSON(hooks)
+}
+
+// @Summary Remove a webhook from a room
+// @Tags Webhooks
+// @Param pincode path string true "Pin Code of the Room"
+// @Param id path string true "ID of the Webhook"
+// @Security FacilitatorToken
+// @Success 204
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/webhooks/{id} [delete]
+func deleteWebhook(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := facilitatorRoom(c, db)
+	if err != nil {
+		return err
+	}
+
+	ref := db.Collection("rooms").Doc(room.Id).Collection("webhooks").Doc(c.Params("id"))
+	if snap, err := ref.Get(ctx); err != nil || !snap.Exists() {
+		return apierror.New(apierror.NotFound, "webhook not found")
+	}
+
+	if _, err := ref.Delete(ctx); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// @Summary Get the latest webhook deliveries of a room
+// @Tags Webhooks
+// @Param pincode path string true "Pin Code of the Room"
+// @Security FacilitatorToken
+// @Produce json
+// @Success 200 {array} webhooks.Delivery
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/rooms/{pincode}/webhooks/deliveries [get]
+func getDeliveries(c *fiber.Ctx) error {
+
+	ctx := utils.Context(c)
+
+	db := new(firestore.Client)
+	container.Make(&db)
+
+	room, err := facilitatorRoom(c, db)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := db.Collection("rooms").Doc(room.Id).Collection("webhook_deliveries").
+		OrderBy("timestamp", firestore.Desc).Limit(deliveriesLimit).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	deliveries := make([]webhooks.Delivery, len(snaps))
+	for i, snap := range snaps {
+		if err := snap.DataTo(&deliveries[i]); err != nil {
+			return err
+		}
+		deliveries[i].Id = snap.Ref.ID
+	}
+
+	return c.JSON(deliveries)
+}
+
+// Registrar endpoints
+func Register(router fiber.Router) {
+
+	webhook := router.Group("/rooms/:pincode/webhooks")
+
+	webhook.Post("", newWebhook)
+	webhook.Get("", getWebhooks)
+	webhook.Get("deliveries", getDeliveries)
+	webhook.Delete(":id", deleteWebhook)
+}
@@ -0,0 +1,302 @@
+package webhooks
+
+import (
+	"bytes"
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	webhooksDispatcher "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/test"
+	"golang.org/x/net/nettest"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var app *fiber.App
+var db *firestore.Client
+var ctx context.Context
+
+func TestMain(m *testing.M) {
+
+	ctx = context.Background()
+
+	conf := config.Default()
+	conf.Webhooks.AllowPrivate = true
+	_ = di.SetupDependencies(conf)
+	container.Make(&db)
+
+	app = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	rooms.Register(app)
+	Register(app)
+
+	listener, _ := nettest.NewLocalListener("tcp")
+	go func() {
+		_ = app.Listener(listener)
+	}()
+
+	m.Run()
+
+	defer func() {
+		db.Close()
+		_ = app.Shutdown()
+	}()
+}
+
+func createRoom(assert *Assert.Assertions) (string, *firestore.DocumentRef, string) {
+
+	token, _ := utils.NewToken()
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+
+	roomDoc := db.Collection("rooms").NewDoc()
+	_, err := roomDoc.Set(ctx, map[string]interface{}{
+		"name":              "Room",
+		"pincode":           pinCode,
+		"facilitator_token": utils.HashToken(token),
+		"timestamp":         firestore.ServerTimestamp,
+	})
+	assert.NoError(err)
+
+	return pinCode, roomDoc, token
+}
+
+func request(method, path, token string, body interface{}) (*http.Response, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return app.Test(req, 30000)
+}
+
+func TestNewWebhookDeliversEvents(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, roomDoc, token := createRoom(assert)
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	res, err := request("POST", fmt.Sprintf("/rooms/%s/webhooks", pinCode), token, webhooks.WebhookNewRequest{
+		Url:    receiver.URL,
+		Events: []string{webhooks.EventPlayerJoined},
+	})
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	result := new(webhooks.WebhookNewResponse)
+	assert.NoError(json.Unmarshal(bodyResp, result))
+	assert.NotEmpty(result.Id)
+	assert.NotEmpty(result.Secret)
+	assert.Equal(receiver.URL, result.Url)
+
+	// Joining the room triggers the webhook
+	res, err = request("POST", fmt.Sprintf("/rooms/%s/join", pinCode), "", map[string]string{"player_name": "Ana"})
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		assert.Equal(webhooks.EventPlayerJoined, r.Header.Get(webhooksDispatcher.HeaderEvent))
+		assert.Equal("sha256="+webhooksDispatcher.Sign(result.Secret, r.Header.Get(webhooksDispatcher.HeaderTimestamp), body),
+			r.Header.Get(webhooksDispatcher.HeaderSignature))
+	case <-time.After(10 * time.Second):
+		assert.Fail("the webhook was not called")
+	}
+
+	// The delivery is logged
+	assert.Eventually(func() bool {
+		snaps, _ := roomDoc.Collection("webhook_deliveries").Documents(ctx).GetAll()
+		return len(snaps) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	res, err = request("GET", fmt.Sprintf("/rooms/%s/webhooks/deliveries", pinCode), token, nil)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ = ioutil.ReadAll(res.Body)
+	deliveries := make([]webhooks.Delivery, 0)
+	assert.NoError(json.Unmarshal(bodyResp, &deliveries))
+	assert.Len(deliveries, 1)
+	assert.True(deliveries[0].Success)
+	assert.Equal(result.Id, deliveries[0].WebhookId)
+}
+
+func TestNewWebhookInvalidUrl(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	res, err := request("POST", fmt.Sprintf("/rooms/%s/webhooks", pinCode), token, webhooks.WebhookNewRequest{
+		Url: "not a url",
+	})
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the url of the webhook must be an http(s) URL",
+		Fields: []models.FieldError{
+			{Field: "url", Message: "the url of the webhook must be an http(s) URL"},
+		},
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestNewWebhookWithoutToken(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, _ := createRoom(assert)
+
+	res, err := request("POST", fmt.Sprintf("/rooms/%s/webhooks", pinCode), "", webhooks.WebhookNewRequest{
+		Url: "https://example.com/hooks",
+	})
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      401,
+		ErrorCode: "unauthorized",
+		Message:   "only the facilitator of the room can manage webhooks",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestNewWebhookPrivateUrl(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	conf := new(config.Config)
+	container.Make(&conf)
+	conf.Webhooks.AllowPrivate = false
+	defer func() {
+		conf.Webhooks.AllowPrivate = true
+	}()
+
+	body := webhooks.WebhookNewRequest{
+		Url: "http://127.0.0.1/hooks",
+	}
+
+	// The url is only checked for the facilitator
+	res, err := request("POST", fmt.Sprintf("/rooms/%s/webhooks", pinCode), "", body)
+	assert.NoError(err)
+	assert.Equal(401, res.StatusCode)
+
+	res, err = request("POST", fmt.Sprintf("/rooms/%s/webhooks", pinCode), token, body)
+	assert.NoError(err)
+	assert.Equal(400, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the url of the webhook must not point to a private address",
+		Fields: []models.FieldError{
+			{Field: "url", Message: "the url of the webhook must not point to a private address"},
+		},
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestGetAndDeleteWebhooks(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode, _, token := createRoom(assert)
+
+	res, err := request("POST", fmt.Sprintf("/rooms/%s/webhooks", pinCode), token, webhooks.WebhookNewRequest{
+		Url: "https://example.com/hooks",
+	})
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	res, err = request("GET", fmt.Sprintf("/rooms/%s/webhooks", pinCode), token, nil)
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	hooks := make([]map[string]interface{}, 0)
+	assert.NoError(json.Unmarshal(bodyResp, &hooks))
+	assert.Len(hooks, 1)
+	assert.Equal("https://example.com/hooks", hooks[0]["url"])
+	assert.NotContains(hooks[0], "secret")
+
+	res, err = request("DELETE", fmt.Sprintf("/rooms/%s/webhooks/%s", pinCode, hooks[0]["id"]), token, nil)
+	assert.NoError(err)
+	assert.Equal(204, res.StatusCode)
+
+	res, err = request("DELETE", fmt.Sprintf("/rooms/%s/webhooks/%s", pinCode, hooks[0]["id"]), token, nil)
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+}
+
+func TestGetWebhooksThatRoomNotExists(t *testing.T) {
+
+	assert := Assert.New(t)
+	pinCode := fmt.Sprintf("%06d", rand.Intn(999999))
+
+	res, err := request("GET", fmt.Sprintf("/rooms/%s/webhooks", pinCode), "token", nil)
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+}
+
+func TestRegisterRoutes(t *testing.T) {
+
+	_ = Assert.New(t)
+
+	router := new(test.MockRouter)
+	router.On("Group", "/rooms/:pincode/webhooks", mock.Anything).Return(router)
+	router.On("Post", "", mock.Anything).Return(router)
+	router.On("Get", "", mock.Anything).Return(router)
+	router.On("Get", "deliveries", mock.Anything).Return(router)
+	router.On("Delete", ":id", mock.Anything).Return(router)
+
+	Register(router)
+
+	router.AssertExpectations(t)
+
+}
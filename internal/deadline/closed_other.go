@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package deadline
+
+import "net"
+
+// closed can't tell on this system whether the peer closed conn.
+func closed(conn net.Conn) bool {
+	return false
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package deadline
+
+import (
+	"net"
+	"syscall"
+)
+
+// closed tells whether the peer of conn closed it, peeking at the socket
+// without taking what the client may have sent, which is left for the server.
+func closed(conn net.Conn) bool {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	gone := false
+	buf := make([]byte, 1)
+	err = raw.Read(func(fd uintptr) bool {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case err == syscall.EAGAIN || err == syscall.EWOULDBLOCK || err == syscall.EINTR:
+		case err != nil:
+			// Reset by the peer
+			gone = true
+		case n == 0:
+			// End of the stream
+			gone = true
+		}
+		return true
+	})
+	return gone || err != nil
+}
@@ -0,0 +1,105 @@
+package deadline
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"sync"
+	"time"
+)
+
+// The context the deadline was set on, and the timeout, so it can be restarted
+const localDeadline = "deadline"
+
+// ClientCheck is how often a watched request checks whether its client is
+// still connected.
+var ClientCheck = time.Second
+
+type deadline struct {
+	parent  context.Context
+	timeout time.Duration
+	// Cancels parent, when the client goes away
+	cancel context.CancelFunc
+}
+
+// New is a Fiber middleware that gives every request a deadline: the calls
+// made with the context of the request (utils.Context) fail once it passes,
+// and the error is answered with 504. It expects the tracing middleware to
+// run before it, so the deadline keeps the span of the request.
+//
+// fasthttp doesn't tell when a client goes away, so only the requests that
+// Watch their client are canceled before their deadline.
+func New(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parent, cancelParent := context.WithCancel(utils.Context(c))
+		defer cancelParent()
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+
+		c.Locals(utils.LocalContext, ctx)
+		c.Locals(localDeadline, deadline{parent: parent, timeout: timeout, cancel: cancelParent})
+
+		return c.Next()
+	}
+}
+
+// Watch checks every ClientCheck whether the client of the request closed its
+// connection, for the requests that wait on purpose, like a long poll. When it
+// did, the returned channel is closed and the context of the request is
+// canceled, so the calls made with it stop and the request is answered with
+// 499. The returned function stops watching; it must be called before the
+// handler returns. Without the middleware, or behind TLS, the client is never
+// seen leaving.
+func Watch(c *fiber.Ctx) (<-chan struct{}, func()) {
+	gone := make(chan struct{})
+	d, ok := c.Locals(localDeadline).(deadline)
+	if !ok {
+		return gone, func() {}
+	}
+
+	conn := c.Context().Conn()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(ClientCheck)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if closed(conn) {
+					d.cancel()
+					close(gone)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return gone, func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// Restart gives the request a new deadline, from now on, for the work it does
+// after waiting on purpose, like a long poll. The returned function releases
+// it. Without the middleware the request is left as it is.
+func Restart(c *fiber.Ctx) context.CancelFunc {
+	d, ok := c.Locals(localDeadline).(deadline)
+	if !ok {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(d.parent, d.timeout)
+	c.Locals(utils.LocalContext, ctx)
+
+	return cancel
+}
@@ -0,0 +1,177 @@
+package deadline
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"golang.org/x/net/nettest"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestDeadline(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: utils.ErrorHandler,
+	})
+	app.Use(New(50 * time.Millisecond))
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		// A storage call that takes longer than the deadline
+		ctx := utils.Context(c)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return c.SendString("done")
+		}
+	})
+
+	start := time.Now()
+	res, err := app.Test(httptest.NewRequest("GET", "/slow", nil), 10000)
+	assert.NoError(err)
+	assert.Equal(504, res.StatusCode)
+	assert.Less(int64(time.Since(start)), int64(time.Second))
+
+	body, _ := ioutil.ReadAll(res.Body)
+	var result models.Error
+	assert.NoError(json.Unmarshal(body, &result))
+	assert.Equal("the storage took too long to answer", result.Message)
+}
+
+func TestRequestWithinDeadline(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Use(New(time.Second))
+	app.Get("/", func(c *fiber.Ctx) error {
+		deadline, ok := utils.Context(c).Deadline()
+		assert.True(ok)
+		assert.WithinDuration(time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		return c.SendString("done")
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+}
+
+func TestRestart(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Use(New(50 * time.Millisecond))
+	app.Get("/", func(c *fiber.Ctx) error {
+		// Waits past the deadline, as a long poll
+		<-utils.Context(c).Done()
+
+		cancel := Restart(c)
+		defer cancel()
+
+		ctx := utils.Context(c)
+		assert.NoError(ctx.Err())
+		deadline, ok := ctx.Deadline()
+		assert.True(ok)
+		assert.WithinDuration(time.Now().Add(50*time.Millisecond), deadline, 20*time.Millisecond)
+		return c.SendString("done")
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+}
+
+func TestRestartWithoutDeadline(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		Restart(c)()
+
+		_, ok := utils.Context(c).Deadline()
+		assert.False(ok)
+		return c.SendString("done")
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+}
+
+func TestWatch(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	ClientCheck = 10 * time.Millisecond
+	defer func() { ClientCheck = time.Second }()
+
+	started := make(chan struct{})
+	canceled := make(chan error, 1)
+
+	app := fiber.New()
+	app.Use(New(time.Minute))
+	app.Get("/wait", func(c *fiber.Ctx) error {
+		gone, stop := Watch(c)
+		defer stop()
+
+		close(started)
+		select {
+		case <-gone:
+			canceled <- utils.Context(c).Err()
+		case <-time.After(5 * time.Second):
+			canceled <- nil
+		}
+		return nil
+	})
+
+	listener, err := nettest.NewLocalListener("tcp")
+	assert.NoError(err)
+	go func() {
+		_ = app.Listener(listener)
+	}()
+	defer app.Shutdown()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if !assert.NoError(err) {
+		return
+	}
+	_, err = conn.Write([]byte("GET /wait HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.NoError(err)
+
+	<-started
+	assert.NoError(conn.Close())
+
+	assert.Equal(context.Canceled, <-canceled)
+}
+
+func TestWatchWithoutDeadline(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		gone, stop := Watch(c)
+		stop()
+
+		select {
+		case <-gone:
+			assert.Fail("the client didn't go away")
+		default:
+		}
+		return c.SendString("done")
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+}
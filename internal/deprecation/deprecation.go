@@ -0,0 +1,38 @@
+package deprecation
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+type Config struct {
+	// When the routes were deprecated
+	Since time.Time
+	// When the routes will be removed. Not announced when zero.
+	Sunset time.Time
+	// Prefix of the routes that replace them, e.g. /v1
+	Successor string
+}
+
+// New is a Fiber middleware that marks the routes it runs for as deprecated,
+// with the Deprecation (RFC 9745) and Sunset (RFC 8594) headers and a link
+// to the same route under the successor prefix.
+func New(conf Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(HeaderDeprecation, fmt.Sprintf("@%d", conf.Since.Unix()))
+		if !conf.Sunset.IsZero() {
+			c.Set(HeaderSunset, conf.Sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Append(HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`, conf.Successor, c.Path()))
+
+		return c.Next()
+	}
+}
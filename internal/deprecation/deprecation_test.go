@@ -0,0 +1,56 @@
+package deprecation
+
+import (
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecation(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Use("/rooms", New(Config{
+		Since:     time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC),
+		Successor: "/v1",
+	}))
+	app.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+		return c.SendString("[]")
+	})
+	app.Get("/v1/rooms/:pincode/players", func(c *fiber.Ctx) error {
+		return c.SendString("[]")
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/rooms/123456/players", nil))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Equal("@1790812800", res.Header.Get(HeaderDeprecation))
+	assert.Equal("Thu, 01 Apr 2027 00:00:00 GMT", res.Header.Get(HeaderSunset))
+	assert.Equal(`</v1/rooms/123456/players>; rel="successor-version"`, res.Header.Get(HeaderLink))
+
+	res, err = app.Test(httptest.NewRequest("GET", "/v1/rooms/123456/players", nil))
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Empty(res.Header.Get(HeaderDeprecation))
+	assert.Empty(res.Header.Get(HeaderLink))
+}
+
+func TestDeprecationWithoutSunset(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Use(New(Config{Since: time.Unix(0, 0), Successor: "/v1"}))
+	app.Get("/rooms", func(c *fiber.Ctx) error {
+		return c.SendStatus(204)
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/rooms", nil))
+	assert.NoError(err)
+	assert.Equal("@0", res.Header.Get(HeaderDeprecation))
+	assert.Empty(res.Header.Get(HeaderSunset))
+}
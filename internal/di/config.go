@@ -0,0 +1,17 @@
+package di
+
+import (
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+)
+
+// SetupConfig makes the configuration available to the other dependencies.
+// It must be called before them.
+func SetupConfig(conf *config.Config) error {
+
+	container.Singleton(func() *config.Config {
+		return conf
+	})
+
+	return nil
+}
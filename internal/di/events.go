@@ -0,0 +1,17 @@
+package di
+
+import (
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+)
+
+// SetupEvents registers the broker that streams the events of the rooms to
+// the clients connected to this instance.
+func SetupEvents() error {
+
+	container.Singleton(func() *events.Broker {
+		return events.NewBroker()
+	})
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package di
+
+import (
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/events"
+	"testing"
+)
+
+func TestSetupEvents(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	err := SetupEvents()
+
+	assert.NoError(err)
+
+	var broker = new(events.Broker)
+	container.Make(&broker)
+
+	assert.NotNil(broker)
+
+}
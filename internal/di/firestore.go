@@ -5,14 +5,63 @@ import (
 	"context"
 	firebase "firebase.google.com/go"
 	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
 )
 
+// emulatorCreds authenticates to the Firestore emulator, the same way the
+// client does when it finds FIRESTORE_EMULATOR_HOST.
+type emulatorCreds struct{}
+
+func (emulatorCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer owner"}, nil
+}
+
+func (emulatorCreds) RequireTransportSecurity() bool {
+	return false
+}
+
+// firestoreOptions measures and traces the calls to the Firestore. The connection to
+// the emulator is dialed here, as the client would ignore the options
+// otherwise.
+func firestoreOptions(conf *config.Config) ([]option.ClientOption, error) {
+	interceptors := []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor(), metrics.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), metrics.StreamClientInterceptor),
+	}
+
+	if len(conf.Firestore.EmulatorHost) > 0 {
+		dialOpts := append([]grpc.DialOption{grpc.WithInsecure(), grpc.WithPerRPCCredentials(emulatorCreds{})}, interceptors...)
+		conn, err := grpc.Dial(conf.Firestore.EmulatorHost, dialOpts...)
+		if err != nil {
+			return nil, err
+		}
+		return []option.ClientOption{option.WithGRPCConn(conn)}, nil
+	}
+
+	opts := make([]option.ClientOption, len(interceptors))
+	for i, interceptor := range interceptors {
+		opts[i] = option.WithGRPCDialOption(interceptor)
+	}
+	return opts, nil
+}
+
 func SetupFirestore() error {
 
-	container.Singleton(func() (*firestore.Client, error) {
+	container.Singleton(func(conf *config.Config) (*firestore.Client, error) {
+		opts, err := firestoreOptions(conf)
+		if err != nil {
+			return nil, err
+		}
+
 		ctx := context.Background()
-		conf := &firebase.Config{}
-		app, err := firebase.NewApp(ctx, conf)
+		fbConf := &firebase.Config{
+			ProjectID: conf.Firestore.ProjectId,
+		}
+		app, err := firebase.NewApp(ctx, fbConf, opts...)
 		if err != nil {
 			return nil, err
 		}
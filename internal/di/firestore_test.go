@@ -4,12 +4,15 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/golobby/container"
 	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
 	"testing"
 )
 
 func TestSetupFirestore(t *testing.T) {
 
 	assert := Assert.New(t)
+	assert.NoError(SetupConfig(config.Default()))
+
 	err := SetupFirestore()
 
 	assert.NoError(err)
@@ -1,9 +1,27 @@
 package di
 
-func SetupDependencies() error {
+import "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+
+func SetupDependencies(conf *config.Config) error {
+	if err := SetupConfig(conf); err != nil {
+		return err
+	}
+
+	if err := SetupTracing(); err != nil {
+		return err
+	}
+
 	if err := SetupFirestore(); err != nil {
 		return err
 	}
 
+	if err := SetupWebhooks(); err != nil {
+		return err
+	}
+
+	if err := SetupEvents(); err != nil {
+		return err
+	}
+
 	return nil
 }
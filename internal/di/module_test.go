@@ -4,13 +4,14 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/golobby/container"
 	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
 	"testing"
 )
 
 func TestSetupDependencies(t *testing.T) {
 
 	assert := Assert.New(t)
-	err := SetupDependencies()
+	err := SetupDependencies(config.Default())
 
 	assert.NoError(err)
 
@@ -0,0 +1,41 @@
+package di
+
+import (
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"os"
+)
+
+// SetupTracing registers the tracer provider with the exporter chosen in the
+// configuration and makes it the global one, with the W3C Trace Context
+// propagator.
+func SetupTracing() error {
+
+	conf := new(config.Config)
+	container.Make(&conf)
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch conf.Tracing.Exporter {
+	case config.TracingStdout:
+		exporter, err = tracing.NewStdoutExporter(os.Stdout)
+	case config.TracingOTLP:
+		exporter, err = tracing.NewOTLPExporter(conf.Tracing.Endpoint)
+	}
+	if err != nil {
+		return err
+	}
+
+	provider := tracing.NewProvider(exporter, conf.Tracing.ServiceName)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(tracing.Propagator())
+
+	container.Singleton(func() *sdktrace.TracerProvider {
+		return provider
+	})
+
+	return nil
+}
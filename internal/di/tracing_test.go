@@ -0,0 +1,29 @@
+package di
+
+import (
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"testing"
+)
+
+func TestSetupTracing(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf := config.Default()
+	conf.Tracing.Exporter = config.TracingStdout
+	assert.NoError(SetupConfig(conf))
+
+	err := SetupTracing()
+
+	assert.NoError(err)
+
+	var provider = new(sdktrace.TracerProvider)
+	container.Make(&provider)
+
+	assert.NotNil(provider)
+	assert.Equal(provider, otel.GetTracerProvider())
+}
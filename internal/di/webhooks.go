@@ -0,0 +1,33 @@
+package di
+
+import (
+	"cloud.google.com/go/firestore"
+	"github.com/golobby/container"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	webhooksDispatcher "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+)
+
+// SetupWebhooks registers the dispatcher of the outgoing webhooks. The
+// webhooks of the configuration receive the events of every room.
+func SetupWebhooks() error {
+
+	container.Singleton(func(conf *config.Config, db *firestore.Client) *webhooksDispatcher.Dispatcher {
+		global := make([]webhooks.Webhook, 0, len(conf.Webhooks.Urls))
+		for _, url := range conf.Webhooks.Urls {
+			global = append(global, webhooks.Webhook{
+				Id:     "global",
+				Url:    url,
+				Secret: conf.Webhooks.Secret,
+			})
+		}
+
+		store := webhooksDispatcher.NewFirestoreStore(db)
+		return webhooksDispatcher.NewDispatcher(webhooksDispatcher.Config{
+			Global:       global,
+			AllowPrivate: conf.Webhooks.AllowPrivate,
+		}, store, store)
+	})
+
+	return nil
+}
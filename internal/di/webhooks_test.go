@@ -0,0 +1,26 @@
+package di
+
+import (
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/webhooks"
+	"testing"
+)
+
+func TestSetupWebhooks(t *testing.T) {
+
+	assert := Assert.New(t)
+	assert.NoError(SetupConfig(config.Default()))
+	assert.NoError(SetupFirestore())
+
+	err := SetupWebhooks()
+
+	assert.NoError(err)
+
+	var dispatcher = new(webhooks.Dispatcher)
+	container.Make(&dispatcher)
+
+	assert.NotNil(dispatcher)
+
+}
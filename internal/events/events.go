@@ -0,0 +1,135 @@
+package events
+
+import (
+	"github.com/gofiber/fiber/v2/utils"
+	"sync"
+	"time"
+)
+
+// Buffer is how many events a subscriber may fall behind before it is
+// dropped.
+const Buffer = 64
+
+// EventShutdown is the last event of the subscriptions that are ended by
+// Close, so their clients know to reconnect.
+const EventShutdown = "server.shutdown"
+
+// Event is something that happened in a room, as streamed to its
+// subscribers. It has the shape of the payload of the webhooks.
+type Event struct {
+	Id        string      `json:"id"`
+	Event     string      `json:"event"`
+	RoomId    string      `json:"room_id"`
+	PinCode   string      `json:"pincode"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+func New(roomId, pinCode, event string, data interface{}) Event {
+	return Event{
+		Id:        utils.UUID(),
+		Event:     event,
+		RoomId:    roomId,
+		PinCode:   pinCode,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// Broker hands the events of the rooms to the subscribers connected to this
+// instance. It keeps nothing: who subscribes gets the events from then on.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns the events of a room and the function that stops them.
+// The channel is closed when the subscription stops, when the subscriber
+// falls more than Buffer events behind, and when the broker is closed.
+func (b *Broker) Subscribe(roomId string) (<-chan Event, func()) {
+	ch := make(chan Event, Buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if b.subscribers[roomId] == nil {
+		b.subscribers[roomId] = make(map[chan Event]struct{})
+	}
+	b.subscribers[roomId][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(roomId, ch)
+	}
+}
+
+// Publish sends an event to the subscribers of its room without waiting for
+// them.
+func (b *Broker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.RoomId] {
+		select {
+		case ch <- event:
+		default:
+			// Too slow: better to end the stream than to skip events silently
+			b.remove(event.RoomId, ch)
+		}
+	}
+}
+
+// Subscribers counts the subscribers of a room.
+func (b *Broker) Subscribers(roomId string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[roomId])
+}
+
+// Close ends every subscription with an EventShutdown, so the streams can
+// finish before the server shuts down. A subscriber that is too far behind
+// misses it.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for roomId, chs := range b.subscribers {
+		shutdown := New(roomId, "", EventShutdown, nil)
+		for ch := range chs {
+			select {
+			case ch <- shutdown:
+			default:
+			}
+			b.remove(roomId, ch)
+		}
+	}
+	b.closed = true
+}
+
+func (b *Broker) remove(roomId string, ch chan Event) {
+	chs, ok := b.subscribers[roomId]
+	if !ok {
+		return
+	}
+	if _, ok := chs[ch]; !ok {
+		return
+	}
+	delete(chs, ch)
+	close(ch)
+	if len(chs) == 0 {
+		delete(b.subscribers, roomId)
+	}
+}
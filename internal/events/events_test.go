@@ -0,0 +1,86 @@
+package events
+
+import (
+	Assert "github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPublishToTheSubscribersOfTheRoom(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	broker := NewBroker()
+	first, stopFirst := broker.Subscribe("room-1")
+	second, stopSecond := broker.Subscribe("room-1")
+	other, stopOther := broker.Subscribe("room-2")
+	defer stopFirst()
+	defer stopSecond()
+	defer stopOther()
+
+	broker.Publish(New("room-1", "123456", "player.joined", "Ana"))
+
+	for _, ch := range []<-chan Event{first, second} {
+		event := <-ch
+		assert.Equal("player.joined", event.Event)
+		assert.Equal("123456", event.PinCode)
+		assert.Equal("Ana", event.Data)
+		assert.NotEmpty(event.Id)
+	}
+	assert.Len(other, 0)
+}
+
+func TestUnsubscribe(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	broker := NewBroker()
+	ch, stop := broker.Subscribe("room-1")
+	assert.Equal(1, broker.Subscribers("room-1"))
+
+	stop()
+	stop()
+
+	_, open := <-ch
+	assert.False(open)
+	assert.Equal(0, broker.Subscribers("room-1"))
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	broker := NewBroker()
+	ch, stop := broker.Subscribe("room-1")
+	defer stop()
+
+	for i := 0; i <= Buffer; i++ {
+		broker.Publish(New("room-1", "123456", "player.joined", i))
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	assert.Equal(Buffer, received, "the channel is closed after the events it could hold")
+}
+
+func TestClose(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	broker := NewBroker()
+	ch, _ := broker.Subscribe("room-1")
+
+	broker.Close()
+
+	event := <-ch
+	assert.Equal(EventShutdown, event.Event)
+	assert.Equal("room-1", event.RoomId)
+
+	_, open := <-ch
+	assert.False(open)
+
+	late, _ := broker.Subscribe("room-1")
+	_, open = <-late
+	assert.False(open, "no subscriptions after the broker is closed")
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV      Format = "csv"
+	JSON     Format = "json"
+	Markdown Format = "md"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case CSV:
+		return CSV, nil
+	case JSON:
+		return JSON, nil
+	case Markdown:
+		return Markdown, nil
+	}
+
+	return "", errors.New("the format must be one of csv, json or md")
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	}
+
+	return "application/json; charset=utf-8"
+}
+
+// Story is a single line of the export: the story itself plus what happened
+// while it was being estimated.
+type Story struct {
+	stories.Story
+	Rounds int `json:"rounds"`
+	// The highest attempt at the story, counting the re-votes
+	Attempts int `json:"attempts"`
+	// Seconds spent discussing before the re-votes
+	Discussion   float64        `json:"discussion_seconds"`
+	Distribution map[string]int `json:"distribution"`
+	Participants []string       `json:"participants"`
+}
+
+// NewStory summarizes the rounds played for a story. The vote distribution is
+// taken from the last revealed round, so an export never shows the cards of
+// a round still being voted, and the participants are the players that voted
+// in any round, in the order they joined the room. The anonymous rounds only
+// tell who voted and the cards, never together. The discussions of the
+// re-votes add up. A story the facilitator didn't estimate takes the card of
+// the last revealed round when all the players agreed on a number.
+func NewStory(story stories.Story, rds []rounds.Round, pls []players.Player) Story {
+	result := Story{
+		Story:        story,
+		Rounds:       len(rds),
+		Distribution: make(map[string]int),
+		Participants: make([]string, 0),
+	}
+
+	voted := make(map[string]bool)
+	var last *rounds.Round
+	for i, round := range rds {
+		if round.Revealed && (last == nil || round.Number > last.Number) {
+			last = &rds[i]
+		}
+		if attempt := round.AttemptNumber(); attempt > result.Attempts {
+			result.Attempts = attempt
+		}
+		result.Discussion += round.Discussion
+		for playerId := range round.Votes {
+			voted[playerId] = true
+		}
+		for _, playerId := range round.Voters {
+			voted[playerId] = true
+		}
+	}
+
+	if last != nil {
+		for _, value := range last.Votes {
+			result.Distribution[value]++
+		}
+		for _, value := range last.Cards {
+			result.Distribution[value]++
+		}
+	}
+
+	if len(result.Estimate) == 0 && len(result.Distribution) == 1 {
+		for value := range result.Distribution {
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				result.Estimate = value
+			}
+		}
+	}
+
+	for _, player := range pls {
+		if voted[player.Id] {
+			result.Participants = append(result.Participants, player.Name)
+		}
+	}
+
+	return result
+}
+
+// Writer encodes an export incrementally, so stories can be written as they
+// are read from the database.
+type Writer interface {
+	Begin(room rooms.Room) error
+	Write(story Story) error
+	End() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w), nil
+	case JSON:
+		return newJSONWriter(w), nil
+	case Markdown:
+		return newMarkdownWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// formatDistribution renders a distribution as "3=2; 5=1", ordering numeric
+// cards by value and putting the others (?, coffee...) at the end.
+func formatDistribution(distribution map[string]int) string {
+	values := make([]string, 0, len(distribution))
+	for value := range distribution {
+		values = append(values, value)
+	}
+	rounds.SortCards(values)
+
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%s=%d", value, distribution[value])
+	}
+
+	return strings.Join(parts, "; ")
+}
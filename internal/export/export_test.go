@@ -0,0 +1,249 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"testing"
+)
+
+var room = rooms.Room{
+	Id:      "room",
+	Name:    "Sprint 42",
+	PinCode: "123456",
+}
+
+var story = Story{
+	Story: stories.Story{
+		Id:       "story",
+		Key:      "PKR-1",
+		Title:    "Export | results",
+		Link:     "https://example.com/PKR-1",
+		Estimate: "5",
+	},
+	Rounds:       2,
+	Attempts:     2,
+	Discussion:   150.4,
+	Distribution: map[string]int{"?": 1, "13": 1, "5": 2},
+	Participants: []string{"Ana", "Bob"},
+}
+
+func TestParseFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for value, expected := range map[string]Format{"csv": CSV, "JSON": JSON, " md ": Markdown} {
+		format, err := ParseFormat(value)
+		assert.NoError(err)
+		assert.Equal(expected, format)
+	}
+
+	_, err := ParseFormat("xml")
+	assert.Error(err)
+	assert.Equal("the format must be one of csv, json or md", err.Error())
+}
+
+func TestNewStory(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	pls := []players.Player{{Id: "1", Name: "Ana"}, {Id: "2", Name: "Bob"}, {Id: "3", Name: "Carl"}}
+	rds := []rounds.Round{
+		{Number: 2, Revealed: true, Votes: map[string]string{"1": "5", "2": "5"}},
+		{Number: 1, Revealed: true, Votes: map[string]string{"1": "3", "3": "8"}},
+	}
+
+	result := NewStory(story.Story, rds, pls)
+
+	assert.Equal(2, result.Rounds)
+	assert.Equal(map[string]int{"5": 2}, result.Distribution)
+	assert.Equal([]string{"Ana", "Bob", "Carl"}, result.Participants)
+}
+
+func TestNewStoryWithOpenRound(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	rds := []rounds.Round{
+		{Number: 1, Revealed: true, Votes: map[string]string{"1": "3", "2": "5"}},
+		{Number: 2, Votes: map[string]string{"1": "13"}},
+	}
+
+	result := NewStory(story.Story, rds, nil)
+
+	assert.Equal(2, result.Rounds)
+	assert.Equal(map[string]int{"3": 1, "5": 1}, result.Distribution, "the cards of the open round stay hidden")
+
+	result = NewStory(story.Story, rds[1:], nil)
+	assert.Empty(result.Distribution)
+}
+
+func TestNewStoryReVotes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	rds := []rounds.Round{
+		{Number: 3, Revealed: true, Attempt: 2, Discussion: 90, Votes: map[string]string{"1": "8"}},
+		{Number: 2, Revealed: true, Votes: map[string]string{"1": "3"}},
+		{Number: 4, Revealed: true, Attempt: 3, Discussion: 30.5, Votes: map[string]string{"1": "5"}},
+	}
+
+	result := NewStory(story.Story, rds, nil)
+
+	assert.Equal(3, result.Rounds)
+	assert.Equal(3, result.Attempts)
+	assert.Equal(120.5, result.Discussion)
+	assert.Equal(map[string]int{"5": 1}, result.Distribution)
+}
+
+func TestNewStoryAnonymous(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	pls := []players.Player{{Id: "1", Name: "Ana"}, {Id: "2", Name: "Bob"}, {Id: "3", Name: "Carl"}}
+	rds := []rounds.Round{
+		{Number: 1, Anonymous: true, Revealed: true, Voters: []string{"1", "3"}, Cards: []string{"8", "3"}},
+	}
+
+	result := NewStory(story.Story, rds, pls)
+
+	assert.Equal(map[string]int{"3": 1, "8": 1}, result.Distribution)
+	assert.Equal([]string{"Ana", "Carl"}, result.Participants)
+}
+
+func TestNewStoryEstimate(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	rds := []rounds.Round{
+		{Number: 1, Revealed: true, Votes: map[string]string{"1": "3", "2": "8"}},
+		{Number: 2, Revealed: true, Votes: map[string]string{"1": "5", "2": "5"}},
+	}
+
+	result := NewStory(stories.Story{Id: "story", Estimate: "8"}, rds, nil)
+	assert.Equal("8", result.Estimate, "the estimate of the facilitator comes first")
+
+	result = NewStory(stories.Story{Id: "story"}, rds, nil)
+	assert.Equal("5", result.Estimate, "the cards agreed on")
+
+	rds[1].Votes["2"] = "8"
+	result = NewStory(stories.Story{Id: "story"}, rds, nil)
+	assert.Empty(result.Estimate, "no agreement")
+
+	rds[1].Votes = map[string]string{"1": "?", "2": "?"}
+	result = NewStory(stories.Story{Id: "story"}, rds, nil)
+	assert.Empty(result.Estimate, "not a number")
+}
+
+func TestNewStoryWithoutRounds(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	result := NewStory(story.Story, nil, nil)
+
+	assert.Equal(0, result.Rounds)
+	assert.Equal(0, result.Attempts)
+	assert.Empty(result.Distribution)
+	assert.Empty(result.Participants)
+}
+
+func TestCSVWriter(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(CSV, buf)
+	assert.NoError(err)
+
+	assert.NoError(w.Begin(room))
+	assert.NoError(w.Write(story))
+	assert.NoError(w.End())
+
+	assert.Equal("key,title,link,estimate,rounds,attempts,discussion_seconds,distribution,participants\n"+
+		"PKR-1,Export | results,https://example.com/PKR-1,5,2,2,150,5=2; 13=1; ?=1,Ana; Bob\n", buf.String())
+}
+
+func TestJSONWriter(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(JSON, buf)
+	assert.NoError(err)
+
+	assert.NoError(w.Begin(room))
+	assert.NoError(w.Write(story))
+	assert.NoError(w.Write(story))
+	assert.NoError(w.End())
+
+	result := struct {
+		Room    rooms.Room `json:"room"`
+		Stories []Story    `json:"stories"`
+	}{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(room.Name, result.Room.Name)
+	assert.Len(result.Stories, 2)
+	assert.Equal(story.Key, result.Stories[0].Key)
+	assert.Equal(story.Distribution, result.Stories[1].Distribution)
+}
+
+func TestJSONWriterWithoutStories(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	buf := new(bytes.Buffer)
+	w, _ := NewWriter(JSON, buf)
+
+	assert.NoError(w.Begin(room))
+	assert.NoError(w.End())
+
+	assert.True(json.Valid(buf.Bytes()))
+}
+
+func TestMarkdownWriter(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(Markdown, buf)
+	assert.NoError(err)
+
+	assert.NoError(w.Begin(room))
+	assert.NoError(w.Write(story))
+	assert.NoError(w.End())
+
+	assert.Equal("# Sprint 42 (123456)\n\n"+
+		"| Key | Story | Estimate | Rounds | Attempts | Discussion | Votes | Participants |\n"+
+		"|---|---|---|---|---|---|---|---|\n"+
+		"| PKR-1 | [Export \\| results](https://example.com/PKR-1) | 5 | 2 | 2 | 2m30s | 5=2; 13=1; ?=1 | Ana, Bob |\n", buf.String())
+}
+
+func TestMarkdownWriterLinks(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	write := func(link string) string {
+		buf := new(bytes.Buffer)
+		w, _ := NewWriter(Markdown, buf)
+		linked := story
+		linked.Link = link
+		assert.NoError(w.Write(linked))
+		return buf.String()
+	}
+
+	assert.Contains(write("https://example.com/a (b)|c"), "[Export \\| results](https://example.com/a%20%28b%29%7Cc)")
+	assert.Contains(write("javascript:alert(1)"), "| Export \\| results |")
+	assert.Contains(write("https://example.com/x) ![img](https://evil.example"), "(https://example.com/x%29%20%21%5Bimg%5D%28https://evil.example)")
+}
+
+func TestNewWriterInvalidFormat(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := NewWriter("xml", new(bytes.Buffer))
+	assert.Error(err)
+}
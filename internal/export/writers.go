@@ -0,0 +1,148 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) Begin(_ rooms.Room) error {
+	return cw.write([]string{"key", "title", "link", "estimate", "rounds", "attempts", "discussion_seconds", "distribution", "participants"})
+}
+
+func (cw *csvWriter) Write(story Story) error {
+	return cw.write([]string{
+		story.Key,
+		story.Title,
+		story.Link,
+		story.Estimate,
+		strconv.Itoa(story.Rounds),
+		strconv.Itoa(story.Attempts),
+		strconv.FormatFloat(math.Round(story.Discussion), 'f', -1, 64),
+		formatDistribution(story.Distribution),
+		strings.Join(story.Participants, "; "),
+	})
+}
+
+func (cw *csvWriter) End() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) write(record []string) error {
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonWriter struct {
+	w     io.Writer
+	first bool
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: w, first: true}
+}
+
+func (jw *jsonWriter) Begin(room rooms.Room) error {
+	data, err := json.Marshal(room)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(jw.w, `{"room":%s,"stories":[`, data)
+	return err
+}
+
+func (jw *jsonWriter) Write(story Story) error {
+	data, err := json.Marshal(story)
+	if err != nil {
+		return err
+	}
+	if !jw.first {
+		if _, err := io.WriteString(jw.w, ","); err != nil {
+			return err
+		}
+	}
+	jw.first = false
+	_, err = jw.w.Write(data)
+	return err
+}
+
+func (jw *jsonWriter) End() error {
+	_, err := io.WriteString(jw.w, "]}")
+	return err
+}
+
+type markdownWriter struct {
+	w io.Writer
+}
+
+func newMarkdownWriter(w io.Writer) *markdownWriter {
+	return &markdownWriter{w: w}
+}
+
+func (mw *markdownWriter) Begin(room rooms.Room) error {
+	_, err := fmt.Fprintf(mw.w, "# %s (%s)\n\n| Key | Story | Estimate | Rounds | Attempts | Discussion | Votes | Participants |\n|---|---|---|---|---|---|---|---|\n",
+		escapeMarkdown(room.Name), room.PinCode)
+	return err
+}
+
+func (mw *markdownWriter) Write(story Story) error {
+	title := escapeMarkdown(story.Title)
+	if link, ok := markdownLink(story.Link); ok {
+		title = fmt.Sprintf("[%s](%s)", title, link)
+	}
+
+	_, err := fmt.Fprintf(mw.w, "| %s | %s | %s | %d | %d | %s | %s | %s |\n",
+		escapeMarkdown(story.Key),
+		title,
+		escapeMarkdown(story.Estimate),
+		story.Rounds,
+		story.Attempts,
+		time.Duration(story.Discussion*float64(time.Second)).Round(time.Second),
+		escapeMarkdown(formatDistribution(story.Distribution)),
+		escapeMarkdown(strings.Join(story.Participants, ", ")),
+	)
+	return err
+}
+
+func (mw *markdownWriter) End() error {
+	return nil
+}
+
+var markdownReplacer = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+func escapeMarkdown(value string) string {
+	return markdownReplacer.Replace(value)
+}
+
+// The characters that would end the destination of a link, or the cell of
+// the table
+var linkReplacer = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E", "|", "%7C", "\\", "%5C")
+
+// markdownLink is the destination of a link to a story, which only links to
+// http(s) URLs; the links come from imports, so they are not trusted.
+func markdownLink(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return "", false
+	}
+	return linkReplacer.Replace(u.String()), true
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+func ParseLevel(value string) (Level, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for i, name := range levelNames {
+		if value == name {
+			return Level(i), nil
+		}
+	}
+	if value == "warning" {
+		return Warn, nil
+	}
+
+	return Info, fmt.Errorf("the log level must be one of %s", strings.Join(levelNames, ", "))
+}
+
+// Fields are the details of a log entry, besides its message.
+type Fields map[string]interface{}
+
+// Logger writes one JSON object per line: time, level and message first,
+// then the fields sorted by name.
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+	now   func() time.Time
+}
+
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{
+		w:     w,
+		level: level,
+		now:   time.Now,
+	}
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Log(level Level, msg string, fields Fields) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString(`{"time":`)
+	writeValue(sb, l.now().UTC().Format(time.RFC3339Nano))
+	sb.WriteString(`,"level":`)
+	writeValue(sb, level.String())
+	sb.WriteString(`,"msg":`)
+	writeValue(sb, msg)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sb.WriteByte(',')
+		writeValue(sb, name)
+		sb.WriteByte(':')
+		writeValue(sb, fields[name])
+	}
+	sb.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, sb.String())
+}
+
+func writeValue(sb *strings.Builder, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	sb.Write(data)
+}
+
+func (l *Logger) Debug(msg string, fields Fields) {
+	l.Log(Debug, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields Fields) {
+	l.Log(Info, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields Fields) {
+	l.Log(Warn, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields Fields) {
+	l.Log(Error, msg, fields)
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	Assert "github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestLogger(level Level) (*Logger, *bytes.Buffer) {
+	out := new(bytes.Buffer)
+	logger := New(out, level)
+	logger.now = func() time.Time {
+		return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	}
+	return logger, out
+}
+
+func TestParseLevel(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	level, err := ParseLevel(" DEBUG ")
+	assert.NoError(err)
+	assert.Equal(Debug, level)
+
+	level, err = ParseLevel("warning")
+	assert.NoError(err)
+	assert.Equal(Warn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.EqualError(err, "the log level must be one of debug, info, warn, error")
+}
+
+func TestLog(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	logger, out := newTestLogger(Info)
+	logger.Info("request", Fields{
+		"status":     200,
+		"route":      "/rooms",
+		"error":      errors.New("boom"),
+		"latency_ms": 1.5,
+	})
+
+	assert.Equal(`{"time":"2021-03-01T12:00:00Z","level":"info","msg":"request","error":"boom","latency_ms":1.5,"route":"/rooms","status":200}`+"\n", out.String())
+}
+
+func TestLogLevel(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	logger, out := newTestLogger(Warn)
+	logger.Debug("debug", nil)
+	logger.Info("info", nil)
+	logger.Warn("warn", nil)
+	logger.Error("error", nil)
+
+	assert.Equal(`{"time":"2021-03-01T12:00:00Z","level":"warn","msg":"warn"}`+"\n"+
+		`{"time":"2021-03-01T12:00:00Z","level":"error","msg":"error"}`+"\n", out.String())
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// NewMiddleware is a Fiber middleware that logs every request once it was handled,
+// at warn level for client errors and error level for server errors. It
+// expects the request ID and tracing middlewares to run before it.
+//
+// The errors returned by the handlers are answered here, with the
+// ErrorHandler of the app, so the log has both the error and the status it
+// was answered with. The middlewares that run before it only see the status.
+func NewMiddleware(logger *Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		if err != nil {
+			if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		status := c.Response().StatusCode()
+
+		level := Info
+		switch {
+		case status >= 500:
+			level = Error
+		case status >= 400:
+			level = Warn
+		}
+
+		fields := Fields{
+			"method":     c.Method(),
+			"path":       c.Path(),
+			"route":      c.Route().Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"request_id": utils.RequestId(c),
+		}
+		if sc := trace.SpanContextFromContext(utils.Context(c)); sc.IsValid() {
+			fields["trace_id"] = sc.TraceID().String()
+		}
+		if pinCode := c.Params("pincode"); len(pinCode) > 0 {
+			fields["pincode"] = pinCode
+		}
+		if roomId, ok := c.Locals(utils.LocalRoomId).(string); ok {
+			fields["room_id"] = roomId
+		}
+		if playerId, ok := c.Locals(utils.LocalPlayerId).(string); ok {
+			fields["player_id"] = playerId
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			fields["stack"] = string(panicErr.Stack)
+		}
+
+		logger.Log(level, "request", fields)
+
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestApp(logger *Logger) *fiber.App {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	app.Use(requestid.New(requestid.Config{
+		ContextKey: utils.LocalRequestId,
+	}))
+	app.Use(NewMiddleware(logger))
+	app.Use(NewRecover())
+
+	app.Post("/rooms/:pincode/join", func(c *fiber.Ctx) error {
+		utils.SetRoom(c, "room-1")
+		utils.SetPlayer(c, "player-1")
+		return c.SendStatus(200)
+	})
+	app.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+		return apierror.New(apierror.RoomNotFound, "room not found")
+	})
+	app.Get("/rooms/:pincode/export", func(c *fiber.Ctx) error {
+		return errors.New("rpc error: code = Unavailable desc = connection refused")
+	})
+	app.Get("/rooms/:pincode/stories", func(c *fiber.Ctx) error {
+		panic("nil map")
+	})
+	return app
+}
+
+func lastEntry(assert *Assert.Assertions, log string) map[string]interface{} {
+	lines := strings.Split(strings.TrimSpace(log), "\n")
+	entry := make(map[string]interface{})
+	assert.NoError(json.Unmarshal([]byte(lines[len(lines)-1]), &entry))
+	return entry
+}
+
+func TestMiddleware(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	logger, out := newTestLogger(Info)
+	app := newTestApp(logger)
+
+	req, _ := http.NewRequest("POST", "/rooms/123456/join", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "abc-123")
+	res, err := app.Test(req)
+
+	assert.NoError(err)
+	assert.Equal(200, res.StatusCode)
+	assert.Equal("abc-123", res.Header.Get(fiber.HeaderXRequestID))
+
+	entry := lastEntry(assert, out.String())
+	assert.Equal("info", entry["level"])
+	assert.Equal("request", entry["msg"])
+	assert.Equal("POST", entry["method"])
+	assert.Equal("/rooms/123456/join", entry["path"])
+	assert.Equal("/rooms/:pincode/join", entry["route"])
+	assert.Equal(float64(200), entry["status"])
+	assert.Equal("abc-123", entry["request_id"])
+	assert.Equal("123456", entry["pincode"])
+	assert.Equal("room-1", entry["room_id"])
+	assert.Equal("player-1", entry["player_id"])
+	assert.Contains(entry, "latency_ms")
+}
+
+func TestMiddlewareError(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	logger, out := newTestLogger(Info)
+	app := newTestApp(logger)
+
+	req, _ := http.NewRequest("GET", "/rooms/123456/players", nil)
+	res, err := app.Test(req)
+
+	assert.NoError(err)
+	assert.Equal(404, res.StatusCode)
+
+	requestId := res.Header.Get(fiber.HeaderXRequestID)
+	assert.NotEmpty(requestId, "an ID is generated when the client doesn't send one")
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	jsonBodyResp, _ := models.Error{
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
+		RequestId: requestId,
+	}.ToJson()
+	assert.Equal(jsonBodyResp, string(bodyResp))
+
+	entry := lastEntry(assert, out.String())
+	assert.Equal("warn", entry["level"])
+	assert.Equal(requestId, entry["request_id"])
+	assert.Equal("room not found", entry["error"])
+	assert.NotContains(entry, "room_id")
+}
+
+func TestMiddlewareInternalError(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	logger, out := newTestLogger(Info)
+	app := newTestApp(logger)
+
+	req, _ := http.NewRequest("GET", "/rooms/123456/export", nil)
+	res, err := app.Test(req)
+
+	assert.NoError(err)
+	assert.Equal(500, res.StatusCode)
+
+	// The client only gets the request ID, the log has the error
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	assert.NotContains(string(bodyResp), "rpc error")
+	assert.Contains(string(bodyResp), res.Header.Get(fiber.HeaderXRequestID))
+
+	entry := lastEntry(assert, out.String())
+	assert.Equal("error", entry["level"])
+	assert.Equal(float64(500), entry["status"])
+	assert.Equal("rpc error: code = Unavailable desc = connection refused", entry["error"])
+}
+
+func TestRecover(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	logger, out := newTestLogger(Info)
+	app := newTestApp(logger)
+
+	req, _ := http.NewRequest("GET", "/rooms/123456/stories", nil)
+	res, err := app.Test(req)
+
+	assert.NoError(err)
+	assert.Equal(500, res.StatusCode)
+
+	bodyResp, _ := ioutil.ReadAll(res.Body)
+	result := new(models.Error)
+	assert.NoError(json.Unmarshal(bodyResp, result))
+	assert.Equal("internal server error", result.Message)
+
+	entry := lastEntry(assert, out.String())
+	assert.Equal("error", entry["level"])
+	assert.Equal("panic: nil map", entry["error"])
+	assert.Contains(entry["stack"], "runtime/debug.Stack")
+}
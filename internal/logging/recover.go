@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"runtime/debug"
+)
+
+// PanicError is a panic of a handler, turned into an error.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// NewRecover is a Fiber middleware that turns the panics of the handlers
+// into errors, so they are answered and logged, with their stack, like any
+// other error. It must run after the logging middleware.
+func NewRecover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+
+		return c.Next()
+	}
+}
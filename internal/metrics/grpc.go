@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// operation is the name of the gRPC method, e.g. RunQuery for
+// /google.firestore.v1.Firestore/RunQuery.
+func operation(method string) string {
+	return method[strings.LastIndex(method, "/")+1:]
+}
+
+func observeStorage(method string, start time.Time, err error) {
+	op := operation(method)
+	StorageRequests.WithLabelValues(op, status.Code(err).String()).Inc()
+	StorageRequestDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+// UnaryClientInterceptor measures the calls to the storage, which is
+// reached through gRPC.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	observeStorage(method, start, err)
+	return err
+}
+
+// StreamClientInterceptor measures the streaming calls to the storage, like
+// queries, until their first answer: callers often stop reading after the
+// first document, so the end of the stream may never be seen.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		observeStorage(method, start, err)
+		return nil, err
+	}
+	return &measuredStream{ClientStream: stream, method: method, start: start}, nil
+}
+
+type measuredStream struct {
+	grpc.ClientStream
+	method string
+	start  time.Time
+	once   sync.Once
+}
+
+func (s *measuredStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.once.Do(func() {
+		if err == io.EOF {
+			observeStorage(s.method, s.start, nil)
+		} else {
+			observeStorage(s.method, s.start, err)
+		}
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	Assert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"testing"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	method := "/google.firestore.v1.Firestore/Commit"
+	ok := testutil.ToFloat64(StorageRequests.WithLabelValues("Commit", "OK"))
+	notFound := testutil.ToFloat64(StorageRequests.WithLabelValues("Commit", "NotFound"))
+
+	invoker := func(err error) grpc.UnaryInvoker {
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return err
+		}
+	}
+
+	assert.NoError(UnaryClientInterceptor(context.Background(), method, nil, nil, nil, invoker(nil)))
+	err := status.Error(codes.NotFound, "not found")
+	assert.Equal(err, UnaryClientInterceptor(context.Background(), method, nil, nil, nil, invoker(err)))
+
+	assert.Equal(ok+1, testutil.ToFloat64(StorageRequests.WithLabelValues("Commit", "OK")))
+	assert.Equal(notFound+1, testutil.ToFloat64(StorageRequests.WithLabelValues("Commit", "NotFound")))
+	assert.GreaterOrEqual(sampleCount(StorageRequestDuration.WithLabelValues("Commit")), uint64(2))
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	messages int
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if s.messages == 0 {
+		return io.EOF
+	}
+	s.messages--
+	return nil
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	ok := testutil.ToFloat64(StorageRequests.WithLabelValues("RunQuery", "OK"))
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeStream{messages: 2}, nil
+	}
+	stream, err := StreamClientInterceptor(context.Background(), nil, nil, "/google.firestore.v1.Firestore/RunQuery", streamer)
+	assert.NoError(err)
+
+	for stream.RecvMsg(nil) == nil {
+	}
+
+	assert.Equal(ok+1, testutil.ToFloat64(StorageRequests.WithLabelValues("RunQuery", "OK")), "a stream is measured once")
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"strconv"
+	"time"
+)
+
+// Route label of the requests that didn't match any route, so unknown paths
+// don't create new series
+const unmatchedRoute = "unmatched"
+
+// New is a Fiber middleware that measures the HTTP requests by route.
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		HTTPRequestsInFlight.Inc()
+		defer HTTPRequestsInFlight.Dec()
+
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+
+		// After Next, the route is the last one that matched: a middleware,
+		// mounted at "/", when no handler did
+		path := c.Route().Path
+		if path == "/" && (status == fiber.StatusNotFound || status == fiber.StatusMethodNotAllowed) {
+			path = unmatchedRoute
+		}
+
+		// The method is kept by the new series, past the buffers of the request
+		method := utils.CopyString(c.Method())
+		HTTPRequests.WithLabelValues(method, path, strconv.Itoa(status)).Inc()
+		HTTPRequestDuration.WithLabelValues(method, path).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	Assert "github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// sampleCount is the number of values observed by a histogram.
+func sampleCount(observer prometheus.Observer) uint64 {
+	m := new(dto.Metric)
+	_ = observer.(prometheus.Metric).Write(m)
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMiddleware(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	app.Use(New())
+	app.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+		return c.SendStatus(204)
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusTeapot)
+	})
+
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/rooms/:pincode/players", "204"))
+	for _, path := range []string{"/rooms/123456/players", "/rooms/654321/players"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		res, err := app.Test(req)
+		assert.NoError(err)
+		assert.Equal(204, res.StatusCode)
+	}
+	assert.Equal(before+2, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/rooms/:pincode/players", "204")))
+	assert.GreaterOrEqual(sampleCount(HTTPRequestDuration.WithLabelValues("GET", "/rooms/:pincode/players")), uint64(2))
+
+	before = testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/fail", "418"))
+	req, _ := http.NewRequest("GET", "/fail", nil)
+	_, err := app.Test(req)
+	assert.NoError(err)
+	assert.Equal(before+1, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/fail", "418")))
+
+	before = testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "404"))
+	req, _ = http.NewRequest("GET", "/does/not/exist", nil)
+	_, err = app.Test(req)
+	assert.NoError(err)
+	assert.Equal(before+1, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+
+	assert.Equal(float64(0), testutil.ToFloat64(HTTPRequestsInFlight))
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The metrics are registered with the default registry, which the /metrics
+// endpoint exposes along with the metrics of the Go runtime.
+
+// DiscussionBuckets are the upper bounds, in seconds, of the durations of the
+// discussions, from half a minute to half an hour.
+var DiscussionBuckets = []float64{30, 60, 120, 300, 600, 900, 1800}
+
+// HTTP
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scrumpoker_http_requests_total",
+		Help: "HTTP requests handled, by route and status.",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scrumpoker_http_request_duration_seconds",
+		Help:    "Time to handle the HTTP requests, by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "scrumpoker_http_requests_in_flight",
+		Help: "HTTP requests being handled.",
+	})
+	RateLimited = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_rate_limited_total",
+		Help: "HTTP requests rejected by the rate limit.",
+	})
+)
+
+// Rooms
+var (
+	RoomsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_rooms_created_total",
+		Help: "Rooms created.",
+	})
+	PlayersJoined = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_players_joined_total",
+		Help: "Players that joined a room.",
+	})
+	Votes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_votes_total",
+		Help: "Votes cast, including the changed ones.",
+	})
+	RoundsRevealed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_rounds_revealed_total",
+		Help: "Rounds revealed.",
+	})
+	ReVotes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_revotes_total",
+		Help: "Rounds voted again after a discussion.",
+	})
+	DiscussionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "scrumpoker_discussion_duration_seconds",
+		Help:    "Time discussing the votes of a round before voting again.",
+		Buckets: DiscussionBuckets,
+	})
+	PollsOpened = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scrumpoker_polls_opened_total",
+		Help: "Polls opened.",
+	})
+)
+
+// Storage
+var (
+	StorageRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scrumpoker_storage_requests_total",
+		Help: "Calls to the storage, by operation and gRPC status code.",
+	}, []string{"operation", "code"})
+	StorageRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scrumpoker_storage_request_duration_seconds",
+		Help:    "Time of the calls to the storage, by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+)
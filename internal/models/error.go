@@ -3,11 +3,49 @@ package models
 import "encoding/json"
 
 type Error struct {
-	Code    int    `json:"code"`
+	// HTTP status
+	Code int `json:"code"`
+	// Stable code of the error, e.g. room_not_found
+	ErrorCode string `json:"error"`
+	Message   string `json:"message"`
+	// Fields that failed validation
+	Fields []FieldError `json:"fields,omitempty"`
+	// ID of the request, to find it in the logs
+	RequestId string `json:"request_id,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
 
 func (err Error) ToJson() (string, error) {
 	str, e := json.Marshal(err)
 	return string(str), e
-}
\ No newline at end of file
+}
+
+// Problem is the error as RFC 7807 problem details, sent to the clients that
+// accept application/problem+json.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Code      string       `json:"code"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestId string       `json:"request_id,omitempty"`
+}
+
+// NewProblem has no type URI to point to, so it uses about:blank and the
+// status text as title, as the RFC suggests.
+func NewProblem(err Error, title string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    err.Code,
+		Detail:    err.Message,
+		Code:      err.ErrorCode,
+		Fields:    err.Fields,
+		RequestId: err.RequestId,
+	}
+}
@@ -0,0 +1,19 @@
+package health
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check is the outcome of checking one dependency of the service.
+type Check struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Response struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
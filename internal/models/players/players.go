@@ -2,8 +2,28 @@ package players
 
 import "time"
 
+const (
+	RoleVoter = "voter"
+	// Follows the room without voting
+	RoleObserver = "observer"
+)
+
+var Roles = []string{RoleVoter, RoleObserver}
+
 type Player struct {
 	Id       string    `json:"id"`
 	Name     string    `json:"name" firestore:"name"`
 	JoinedAt time.Time `json:"joined_at" firestore:"timestamp"`
+	Role     string    `json:"role" firestore:"role"`
+	// Whether the player follows the events of the room
+	Online bool `json:"online" firestore:"online"`
+	// Whether the player voted in the latest round of the room
+	Voted bool `json:"voted" firestore:"voted"`
+
+	// Hash of the token given to the player when joining
+	Token string `json:"-" firestore:"token"`
+	// The latest round the player voted in
+	VotedRound int `json:"-" firestore:"voted_round"`
+	// How many streams of events the player follows
+	Connections int `json:"-" firestore:"connections"`
 }
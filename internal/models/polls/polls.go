@@ -0,0 +1,175 @@
+package polls
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/validation"
+	"time"
+)
+
+// The kinds of polls
+const (
+	// Every player chooses one of the options
+	Single = "single"
+	// Every player places up to Dots dots on the options, several on the same
+	// one if they like
+	Multi = "multi"
+	// Every player answers yes, no or abstain
+	YesNo = "yes_no"
+)
+
+var Kinds = []string{Single, Multi, YesNo}
+
+// The options of the yes/no polls
+var YesNoOptions = []string{"yes", "no", "abstain"}
+
+const (
+	MaxQuestionLength = 200
+	MaxOptionLength   = 100
+	MinOptions        = 2
+	MaxOptions        = 20
+	MaxDots           = 10
+)
+
+// Poll is a quick decision of a room, voted on by its players apart from the
+// rounds. Its results are shown once it is closed.
+type Poll struct {
+	Id        string     `json:"id"`
+	Question  string     `json:"question" firestore:"question"`
+	Kind      string     `json:"kind" firestore:"kind"`
+	Options   []string   `json:"options" firestore:"options"`
+	Dots      int        `json:"dots,omitempty" firestore:"dots"`
+	Closed    bool       `json:"closed" firestore:"closed"`
+	CreatedAt time.Time  `json:"created_at" firestore:"timestamp"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty" firestore:"closed_at"`
+
+	// The choices of each player
+	Ballots map[string][]string `json:"-" firestore:"ballots"`
+	// How many players voted
+	Voters int `json:"voters" firestore:"-"`
+	// The options with their votes, in the order of the options, once the
+	// poll is closed
+	Results []Result `json:"results,omitempty" firestore:"-"`
+}
+
+type Result struct {
+	Option string `json:"option"`
+	Votes  int    `json:"votes"`
+}
+
+// Tally counts the voters and, when the poll is closed, the votes of each
+// option.
+func (poll *Poll) Tally() {
+	poll.Voters = len(poll.Ballots)
+	if !poll.Closed {
+		poll.Results = nil
+		return
+	}
+
+	votes := make(map[string]int)
+	for _, choices := range poll.Ballots {
+		for _, choice := range choices {
+			votes[choice]++
+		}
+	}
+
+	poll.Results = make([]Result, len(poll.Options))
+	for i, option := range poll.Options {
+		poll.Results[i] = Result{Option: option, Votes: votes[option]}
+	}
+}
+
+// Check tells what is wrong with the choices of a player, or returns an
+// empty string when they fit the poll.
+func (poll *Poll) Check(choices []string) string {
+	switch {
+	case len(choices) == 0:
+		return "choose at least one option"
+	case poll.Kind == Multi && len(choices) > poll.Dots:
+		return fmt.Sprintf("place at most %d dots", poll.Dots)
+	case poll.Kind != Multi && len(choices) > 1:
+		return "choose only one option"
+	}
+
+	for _, choice := range choices {
+		found := false
+		for _, option := range poll.Options {
+			if choice == option {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%q is not an option of the poll", choice)
+		}
+	}
+	return ""
+}
+
+// PollNewRequest opens a poll. The yes/no polls have their own options; the
+// others need from 2 to 20, and the multi choice ones the dots of each
+// player.
+type PollNewRequest struct {
+	Question string   `json:"question"`
+	Kind     string   `json:"kind"`
+	Options  []string `json:"options"`
+	Dots     int      `json:"dots"`
+}
+
+func (body *PollNewRequest) Validate() error {
+	v := validation.New()
+	v.Text("question", "the question", &body.Question,
+		validation.Required, validation.MaxLength(MaxQuestionLength), validation.NoControl)
+	v.Text("kind", "the kind of poll", &body.Kind, validation.Required, validation.OneOf(Kinds...))
+
+	if body.Kind == YesNo {
+		if len(body.Options) > 0 {
+			v.Add("options", "the yes/no polls have their own options")
+		}
+	} else if len(body.Options) < MinOptions || len(body.Options) > MaxOptions {
+		v.Add("options", fmt.Sprintf("the poll must have from %d to %d options", MinOptions, MaxOptions))
+	}
+
+	seen := make(map[string]bool)
+	for i := range body.Options {
+		field := fmt.Sprintf("options[%d]", i)
+		v.Text(field, "the option", &body.Options[i],
+			validation.Required, validation.MaxLength(MaxOptionLength), validation.NoControl)
+		if len(body.Options[i]) > 0 && seen[body.Options[i]] {
+			v.Add(field, "the option is repeated")
+		}
+		seen[body.Options[i]] = true
+	}
+
+	if body.Kind == Multi {
+		if body.Dots < 1 || body.Dots > MaxDots {
+			v.Add("dots", fmt.Sprintf("the dots must be from 1 to %d", MaxDots))
+		}
+	} else if body.Dots != 0 {
+		v.Add("dots", "only the multi choice polls have dots")
+	}
+
+	return v.Err()
+}
+
+type PollVoteRequest struct {
+	PlayerId string `json:"player_id"`
+	// The chosen options; a multi choice poll takes one per dot
+	Choices []string `json:"choices"`
+}
+
+func (body *PollVoteRequest) Validate() error {
+	v := validation.New()
+	v.Text("player_id", "the player", &body.PlayerId, validation.Required, validation.NoControl)
+	if len(body.Choices) == 0 {
+		v.Add("choices", "the choices are required")
+	}
+
+	return v.Err()
+}
+
+// PollBallot tells that a player voted in a poll, without telling the
+// choices.
+type PollBallot struct {
+	PollId   string `json:"poll_id"`
+	PlayerId string `json:"player_id"`
+}
@@ -0,0 +1,98 @@
+package polls
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTally(t *testing.T) {
+
+	poll := Poll{
+		Kind:    Multi,
+		Options: []string{"Pizza", "Sushi", "Tacos"},
+		Dots:    3,
+		Ballots: map[string][]string{
+			"1": {"Pizza", "Pizza", "Tacos"},
+			"2": {"Tacos"},
+		},
+	}
+
+	poll.Tally()
+
+	assert.Equal(t, 2, poll.Voters)
+	assert.Nil(t, poll.Results)
+
+	poll.Closed = true
+	poll.Tally()
+
+	assert.Equal(t, []Result{{"Pizza", 2}, {"Sushi", 0}, {"Tacos", 2}}, poll.Results)
+
+}
+
+func TestPollClosedAt(t *testing.T) {
+
+	open, err := json.Marshal(Poll{Question: "Lunch?"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(open), "closed_at")
+
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	closed, err := json.Marshal(Poll{Question: "Lunch?", Closed: true, ClosedAt: &at})
+	assert.NoError(t, err)
+	assert.Contains(t, string(closed), `"closed_at":"2026-10-19T12:00:00Z"`)
+
+}
+
+func TestCheck(t *testing.T) {
+
+	single := Poll{Kind: Single, Options: []string{"A", "B"}}
+	assert.Empty(t, single.Check([]string{"B"}))
+	assert.Equal(t, "choose only one option", single.Check([]string{"A", "B"}))
+	assert.Equal(t, "choose at least one option", single.Check(nil))
+	assert.Equal(t, `"C" is not an option of the poll`, single.Check([]string{"C"}))
+
+	multi := Poll{Kind: Multi, Options: []string{"A", "B"}, Dots: 2}
+	assert.Empty(t, multi.Check([]string{"A", "A"}))
+	assert.Equal(t, "place at most 2 dots", multi.Check([]string{"A", "A", "B"}))
+
+	yesNo := Poll{Kind: YesNo, Options: YesNoOptions}
+	assert.Empty(t, yesNo.Check([]string{"abstain"}))
+
+}
+
+func TestPollNewRequestValid(t *testing.T) {
+
+	body := PollNewRequest{Question: " Lunch? ", Kind: Multi, Options: []string{"Pizza", " Sushi"}, Dots: 3}
+	assert.NoError(t, body.Validate())
+	assert.Equal(t, "Lunch?", body.Question)
+	assert.Equal(t, "Sushi", body.Options[1])
+
+	body = PollNewRequest{Question: "Ship it?", Kind: YesNo}
+	assert.NoError(t, body.Validate())
+
+}
+
+func TestPollNewRequestInvalid(t *testing.T) {
+
+	body := PollNewRequest{Kind: "ranked"}
+	assert.EqualError(t, body.Validate(), "the question is required; the kind of poll must be one of single, multi, yes_no; "+
+		"the poll must have from 2 to 20 options")
+
+	body = PollNewRequest{Question: "Lunch?", Kind: Single, Options: []string{"Pizza", "Pizza"}, Dots: 2}
+	assert.EqualError(t, body.Validate(), "the option is repeated; only the multi choice polls have dots")
+
+	body = PollNewRequest{Question: "Lunch?", Kind: Multi, Options: []string{"Pizza", "Sushi"}}
+	assert.EqualError(t, body.Validate(), "the dots must be from 1 to 10")
+
+	body = PollNewRequest{Question: "Ship it?", Kind: YesNo, Options: []string{"maybe"}}
+	assert.EqualError(t, body.Validate(), "the yes/no polls have their own options")
+
+}
+
+func TestPollVoteRequestInvalid(t *testing.T) {
+
+	body := PollVoteRequest{}
+	assert.EqualError(t, body.Validate(), "the player is required; the choices are required")
+
+}
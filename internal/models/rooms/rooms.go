@@ -1,49 +1,86 @@
 package rooms
 
 import (
-	"errors"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/validation"
 	"strings"
 	"time"
 )
 
+const (
+	MaxRoomNameLength   = 100
+	MaxPlayerNameLength = 50
+)
+
+// Settings are how a room plays, chosen when it is created.
+type Settings struct {
+	// The revealed votes only show the cards, shuffled, without who played
+	// them
+	Anonymous bool `json:"anonymous" firestore:"anonymous"`
+	// Every vote comes with how confident the player is, from 1 to 5, as
+	// with a fist of five
+	Confidence bool `json:"confidence" firestore:"confidence"`
+}
+
 type Room struct {
 	Id        string    `json:"id"`
 	Name      string    `json:"name" firestore:"name"`
 	PinCode   string    `json:"pincode" firestore:"pincode"`
 	CreatedAt time.Time `json:"created_at" firestore:"timestamp"`
+	Settings
+
+	// Hash of the token given to whoever created the room
+	FacilitatorToken string `json:"-" firestore:"facilitator_token"`
+	// The Slack user who created the room with the slash command, the only
+	// one who can reveal its rounds in Slack
+	SlackFacilitator string `json:"-" firestore:"slack_facilitator"`
+	// Bumped on every change of the players, the rounds or the votes; the
+	// ETag of the room
+	Version int64 `json:"-" firestore:"version"`
 }
 
 type RoomNewRequest struct {
 	Name string `json:"name"`
+	Settings
 }
 
 func (body *RoomNewRequest) Validate() error {
-	if len(strings.TrimSpace(body.Name)) == 0 {
-		return errors.New("the name of the room is required")
-	}
+	v := validation.New()
+	v.Text("name", "the name of the room", &body.Name,
+		validation.Required, validation.MaxLength(MaxRoomNameLength), validation.NoControl)
 
-	return nil
+	return v.Err()
 }
 
 type RoomNewResponse struct {
-	RoomId  string `json:"room_id"`
-	PinCode string `json:"pincode"`
+	RoomId           string `json:"room_id"`
+	PinCode          string `json:"pincode"`
+	FacilitatorToken string `json:"facilitator_token"`
 }
 
 type RoomJoinRequest struct {
 	PlayerName string `json:"player_name"`
+	// voter, the default, or observer
+	Role string `json:"role"`
 }
 
 func (body *RoomJoinRequest) Validate() error {
-	if len(strings.TrimSpace(body.PlayerName)) == 0 {
-		return errors.New("the name of the player is required")
+	if len(strings.TrimSpace(body.Role)) == 0 {
+		body.Role = players.RoleVoter
 	}
 
-	return nil
+	v := validation.New()
+	v.Text("player_name", "the name of the player", &body.PlayerName,
+		validation.Required, validation.MaxLength(MaxPlayerNameLength), validation.NoControl)
+	v.Text("role", "the role of the player", &body.Role, validation.OneOf(players.Roles...))
+
+	return v.Err()
 }
 
 type RoomJoinResponse struct {
 	Room       Room   `json:"room"`
 	PlayerId   string `json:"id"`
 	PlayerName string `json:"name"`
+	// Authorizes the votes of the player. It is only shown once.
+	PlayerToken string `json:"player_token"`
 }
@@ -2,6 +2,7 @@ package rooms
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -29,6 +30,21 @@ func TestRoomJoinRequestValid(t *testing.T) {
 		PlayerName: "thiago",
 	}
 	assert.NoError(t, room.Validate())
+	assert.Equal(t, "voter", room.Role)
+
+}
+
+func TestRoomJoinRequestRole(t *testing.T) {
+
+	room := RoomJoinRequest{
+		PlayerName: "thiago",
+		Role:       " observer ",
+	}
+	assert.NoError(t, room.Validate())
+	assert.Equal(t, "observer", room.Role)
+
+	room.Role = "facilitator"
+	assert.EqualError(t, room.Validate(), "the role of the player must be one of voter, observer")
 
 }
 
@@ -40,3 +56,34 @@ func TestRoomJoinRequestInvalid(t *testing.T) {
 	assert.Errorf(t, room.Validate(), "the name of the room is required")
 
 }
+
+func TestRoomNewRequestCleansName(t *testing.T) {
+
+	// "é" written as "e" and a combining accent
+	room := RoomNewRequest{
+		Name: "  Caf\u0065\u0301  ",
+	}
+	assert.NoError(t, room.Validate())
+	assert.Equal(t, "Caf\u00e9", room.Name)
+
+}
+
+func TestRoomNewRequestNameTooLong(t *testing.T) {
+
+	room := RoomNewRequest{
+		Name: strings.Repeat("á", MaxRoomNameLength+1),
+	}
+	assert.EqualError(t, room.Validate(), "the name of the room must have at most 100 characters")
+
+}
+
+func TestRoomJoinRequestControlCharacters(t *testing.T) {
+
+	for _, name := range []string{"Ana\nBob", "Ana\u202eboB"} {
+		room := RoomJoinRequest{
+			PlayerName: name,
+		}
+		assert.EqualError(t, room.Validate(), "the name of the player must not contain control characters")
+	}
+
+}
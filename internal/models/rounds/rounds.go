@@ -0,0 +1,311 @@
+package rounds
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/validation"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultDeck is the card deck used by the rooms: Fibonacci numbers plus
+// "?" (no idea) and "☕" (need a break).
+var DefaultDeck = []string{"0", "1", "2", "3", "5", "8", "13", "21", "?", "☕"}
+
+// IsCard tells whether value is one of the cards of the deck.
+func IsCard(value string) bool {
+	for _, card := range DefaultDeck {
+		if card == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SortCards orders card values the way they appear in the deck: numbers by
+// value, then the other cards ("?", "☕"...) alphabetically.
+func SortCards(values []string) {
+	sort.Slice(values, func(i, j int) bool {
+		a, errA := strconv.ParseFloat(values[i], 64)
+		b, errB := strconv.ParseFloat(values[j], 64)
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil:
+			return true
+		case errB == nil:
+			return false
+		}
+		return values[i] < values[j]
+	})
+}
+
+type Round struct {
+	Number     int               `json:"number" firestore:"number"`
+	StoryId    string            `json:"story_id" firestore:"story_id"`
+	Votes      map[string]string `json:"votes" firestore:"votes"`
+	Revealed   bool              `json:"revealed" firestore:"revealed"`
+	CreatedAt  time.Time         `json:"created_at" firestore:"timestamp"`
+	RevealedAt *time.Time        `json:"revealed_at,omitempty" firestore:"revealed_at"`
+
+	// Whether the room was anonymous when the round started. Such a round
+	// has no Votes: it keeps who voted and, apart, the sorted cards, so no
+	// card is ever stored with its player.
+	Anonymous bool     `json:"anonymous" firestore:"anonymous"`
+	Voters    []string `json:"-" firestore:"voters"`
+	Cards     []string `json:"-" firestore:"cards"`
+
+	// Attempt at estimating the story, from 1. A re-vote is the next attempt
+	// of the round before it, which keeps its votes.
+	Attempt int `json:"attempt" firestore:"attempt"`
+	// Seconds from the reveal of the previous attempt to the re-vote
+	Discussion float64 `json:"discussion_seconds,omitempty" firestore:"discussion_seconds"`
+
+	// Whether the room asked for a confidence with every vote when the round
+	// started. The confidences are kept by player, or apart like the cards
+	// in an anonymous round.
+	Confidence       bool           `json:"confidence" firestore:"confidence"`
+	Confidences      map[string]int `json:"-" firestore:"confidences"`
+	ConfidenceLevels []int          `json:"-" firestore:"confidence_levels"`
+}
+
+const (
+	MinConfidence = 1
+	MaxConfidence = 5
+)
+
+// AttemptNumber is the attempt of a round; the rounds started before the
+// re-votes are first attempts.
+func (round *Round) AttemptNumber() int {
+	if round.Attempt < 1 {
+		return 1
+	}
+	return round.Attempt
+}
+
+// VoteAnonymously adds the card and the confidence of a player to an
+// anonymous round. The cards and the confidences are sorted, so their order
+// can't tell who played them either. A player votes once: nothing tells
+// which card to replace.
+func (round *Round) VoteAnonymously(playerId, value string, confidence int) bool {
+	if round.Voted(playerId) {
+		return false
+	}
+
+	round.Voters = append(round.Voters, playerId)
+	sort.Strings(round.Voters)
+	round.Cards = append(round.Cards, value)
+	SortCards(round.Cards)
+	if round.Confidence {
+		round.ConfidenceLevels = append(round.ConfidenceLevels, confidence)
+		sort.Ints(round.ConfidenceLevels)
+	}
+	return true
+}
+
+// Shuffle shuffles the cards and the confidences of an anonymous round with
+// shuffle.
+func (round *Round) Shuffle(shuffle func(n int, swap func(i, j int))) {
+	shuffle(len(round.Cards), func(i, j int) {
+		round.Cards[i], round.Cards[j] = round.Cards[j], round.Cards[i]
+	})
+	shuffle(len(round.ConfidenceLevels), func(i, j int) {
+		round.ConfidenceLevels[i], round.ConfidenceLevels[j] = round.ConfidenceLevels[j], round.ConfidenceLevels[i]
+	})
+}
+
+// Voted tells whether a player voted in a round.
+func (round *Round) Voted(playerId string) bool {
+	if round.Anonymous {
+		for _, voter := range round.Voters {
+			if voter == playerId {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := round.Votes[playerId]
+	return ok
+}
+
+// RoundNewRequest starts a round, for a story of the room or for none.
+type RoundNewRequest struct {
+	StoryId string `json:"story_id"`
+}
+
+func (body *RoundNewRequest) Validate() error {
+	v := validation.New()
+	v.Text("story_id", "the story", &body.StoryId, validation.NoControl)
+
+	return v.Err()
+}
+
+type VoteRequest struct {
+	PlayerId string `json:"player_id"`
+	Card     string `json:"card"`
+	// From 1 to 5, only in the rooms that ask for it
+	Confidence int `json:"confidence,omitempty"`
+}
+
+func (body *VoteRequest) Validate() error {
+	v := validation.New()
+	v.Text("player_id", "the player", &body.PlayerId, validation.Required, validation.NoControl)
+	v.Text("card", "the card", &body.Card, validation.Required)
+	if body.Confidence != 0 && !IsConfidence(body.Confidence) {
+		v.Add("confidence", fmt.Sprintf("the confidence must be from %d to %d", MinConfidence, MaxConfidence))
+	}
+
+	return v.Err()
+}
+
+func IsConfidence(level int) bool {
+	return level >= MinConfidence && level <= MaxConfidence
+}
+
+// Ballot tells that a player voted in a round, without telling the card.
+type Ballot struct {
+	Round    int    `json:"round"`
+	PlayerId string `json:"player_id"`
+}
+
+type Vote struct {
+	PlayerId   string `json:"player_id"`
+	PlayerName string `json:"player_name"`
+	Value      string `json:"value"`
+	Confidence int    `json:"confidence,omitempty"`
+}
+
+// Confidence is how confident the players of a round are in their votes.
+type Confidence struct {
+	// Count of each level, from "1" to "5"
+	Distribution map[string]int `json:"distribution"`
+	// Absent when nobody voted
+	Average *float64 `json:"average,omitempty"`
+	// The lowest level, which is where the discussion should start
+	Lowest int `json:"lowest,omitempty"`
+}
+
+// Summary is the result of a revealed round, ready to be shown to people.
+type Summary struct {
+	Number     int    `json:"number"`
+	StoryId    string `json:"story_id"`
+	StoryTitle string `json:"story_title"`
+	Attempt    int    `json:"attempt"`
+	// Empty when the round is anonymous
+	Votes     []Vote `json:"votes"`
+	Anonymous bool   `json:"anonymous"`
+	// The cards of an anonymous round, shuffled
+	Cards        []string       `json:"cards,omitempty"`
+	Distribution map[string]int `json:"distribution"`
+	// Average of the numeric votes, absent when there are none
+	Average   *float64 `json:"average,omitempty"`
+	Consensus bool     `json:"consensus"`
+	// Absent unless the room asks for confidence
+	Confidence *Confidence `json:"confidence,omitempty"`
+}
+
+// NewSummary builds the summary of a round, listing the votes in the order
+// the players joined the room. The summary of an anonymous round only has
+// its cards.
+func NewSummary(round Round, storyTitle string, pls []players.Player) Summary {
+	summary := Summary{
+		Number:       round.Number,
+		StoryId:      round.StoryId,
+		StoryTitle:   storyTitle,
+		Attempt:      round.AttemptNumber(),
+		Votes:        make([]Vote, 0, len(round.Votes)),
+		Anonymous:    round.Anonymous,
+		Distribution: make(map[string]int),
+	}
+
+	names := make(map[string]string)
+	order := make(map[string]int)
+	for i, player := range pls {
+		names[player.Id] = player.Name
+		order[player.Id] = i
+	}
+
+	values := round.Cards
+	if !round.Anonymous {
+		values = make([]string, 0, len(round.Votes))
+		for playerId, value := range round.Votes {
+			summary.Votes = append(summary.Votes, Vote{
+				PlayerId:   playerId,
+				PlayerName: names[playerId],
+				Value:      value,
+				Confidence: round.Confidences[playerId],
+			})
+			values = append(values, value)
+		}
+	} else if !round.Revealed {
+		// The cards are not shown before the reveal
+		values = nil
+	} else {
+		summary.Cards = round.Cards
+	}
+
+	sum, count := 0.0, 0
+	for _, value := range values {
+		summary.Distribution[value]++
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			sum += n
+			count++
+		}
+	}
+
+	sort.Slice(summary.Votes, func(i, j int) bool {
+		oi, iKnown := order[summary.Votes[i].PlayerId]
+		oj, jKnown := order[summary.Votes[j].PlayerId]
+		if iKnown != jKnown {
+			return iKnown
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return summary.Votes[i].PlayerId < summary.Votes[j].PlayerId
+	})
+
+	if count > 0 {
+		average := math.Round(sum/float64(count)*100) / 100
+		summary.Average = &average
+	}
+	summary.Consensus = len(summary.Distribution) == 1
+
+	if round.Confidence {
+		summary.Confidence = newConfidence(round)
+	}
+
+	return summary
+}
+
+func newConfidence(round Round) *Confidence {
+	confidence := &Confidence{Distribution: make(map[string]int)}
+
+	levels := round.ConfidenceLevels
+	switch {
+	case round.Anonymous && !round.Revealed:
+		levels = nil
+	case !round.Anonymous:
+		levels = make([]int, 0, len(round.Confidences))
+		for _, level := range round.Confidences {
+			levels = append(levels, level)
+		}
+	}
+
+	sum := 0
+	for _, level := range levels {
+		confidence.Distribution[strconv.Itoa(level)]++
+		sum += level
+		if confidence.Lowest == 0 || level < confidence.Lowest {
+			confidence.Lowest = level
+		}
+	}
+	if len(levels) > 0 {
+		average := math.Round(float64(sum)/float64(len(levels))*100) / 100
+		confidence.Average = &average
+	}
+
+	return confidence
+}
@@ -0,0 +1,182 @@
+package rounds
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"testing"
+	"time"
+)
+
+func TestIsCard(t *testing.T) {
+
+	assert.True(t, IsCard("5"))
+	assert.True(t, IsCard("?"))
+	assert.False(t, IsCard("4"))
+	assert.False(t, IsCard(""))
+
+}
+
+func TestNewSummary(t *testing.T) {
+
+	pls := []players.Player{{Id: "1", Name: "Ana"}, {Id: "2", Name: "Bob"}, {Id: "3", Name: "Carl"}}
+	round := Round{
+		Number:  2,
+		StoryId: "story",
+		Votes:   map[string]string{"3": "?", "1": "3", "2": "8"},
+	}
+
+	summary := NewSummary(round, "Login", pls)
+
+	assert.Equal(t, 2, summary.Number)
+	assert.Equal(t, "Login", summary.StoryTitle)
+	assert.Equal(t, []Vote{
+		{PlayerId: "1", PlayerName: "Ana", Value: "3"},
+		{PlayerId: "2", PlayerName: "Bob", Value: "8"},
+		{PlayerId: "3", PlayerName: "Carl", Value: "?"},
+	}, summary.Votes)
+	assert.Equal(t, map[string]int{"3": 1, "8": 1, "?": 1}, summary.Distribution)
+	assert.Equal(t, 5.5, *summary.Average)
+	assert.False(t, summary.Consensus)
+
+}
+
+func TestNewSummaryConsensus(t *testing.T) {
+
+	summary := NewSummary(Round{Votes: map[string]string{"1": "5", "2": "5"}}, "", nil)
+
+	assert.True(t, summary.Consensus)
+	assert.Equal(t, 5.0, *summary.Average)
+
+}
+
+func TestNewSummaryWithoutNumericVotes(t *testing.T) {
+
+	summary := NewSummary(Round{Votes: map[string]string{"1": "?"}}, "", nil)
+
+	assert.Nil(t, summary.Average)
+	assert.Equal(t, []Vote{{PlayerId: "1", Value: "?"}}, summary.Votes)
+
+}
+
+func TestVoteAnonymously(t *testing.T) {
+
+	round := Round{Anonymous: true, Confidence: true}
+	assert.True(t, round.VoteAnonymously("3", "?", 2))
+	assert.True(t, round.VoteAnonymously("1", "8", 5))
+	assert.True(t, round.VoteAnonymously("2", "3", 1))
+	assert.False(t, round.VoteAnonymously("1", "5", 3))
+
+	assert.Nil(t, round.Votes)
+	assert.Nil(t, round.Confidences)
+	assert.Equal(t, []string{"1", "2", "3"}, round.Voters)
+	assert.Equal(t, []string{"3", "8", "?"}, round.Cards)
+	assert.Equal(t, []int{1, 2, 5}, round.ConfidenceLevels)
+	assert.True(t, round.Voted("2"))
+	assert.False(t, round.Voted("4"))
+
+	reverse := func(n int, swap func(i, j int)) {
+		for i := 0; i < n/2; i++ {
+			swap(i, n-1-i)
+		}
+	}
+	round.Shuffle(reverse)
+
+	assert.Equal(t, []string{"?", "8", "3"}, round.Cards)
+	assert.Equal(t, []int{5, 2, 1}, round.ConfidenceLevels)
+
+}
+
+func TestNewSummaryAnonymous(t *testing.T) {
+
+	pls := []players.Player{{Id: "1", Name: "Ana"}, {Id: "2", Name: "Bob"}}
+	round := Round{
+		Number:    1,
+		Anonymous: true,
+		Revealed:  true,
+		Voters:    []string{"1", "2"},
+		Cards:     []string{"8", "3"},
+	}
+
+	summary := NewSummary(round, "Login", pls)
+
+	assert.True(t, summary.Anonymous)
+	assert.Empty(t, summary.Votes)
+	assert.Equal(t, []string{"8", "3"}, summary.Cards)
+	assert.Equal(t, map[string]int{"3": 1, "8": 1}, summary.Distribution)
+	assert.Equal(t, 5.5, *summary.Average)
+
+}
+
+func TestNewSummaryAnonymousHidden(t *testing.T) {
+
+	summary := NewSummary(Round{Anonymous: true, Voters: []string{"1"}, Cards: []string{"5"}}, "", nil)
+
+	assert.Empty(t, summary.Votes)
+	assert.Empty(t, summary.Cards)
+	assert.Empty(t, summary.Distribution)
+
+}
+
+func TestRoundRevealedAt(t *testing.T) {
+
+	hidden, err := json.Marshal(Round{Number: 1})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(hidden), "revealed_at")
+
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	revealed, err := json.Marshal(Round{Number: 1, Revealed: true, RevealedAt: &at})
+	assert.NoError(t, err)
+	assert.Contains(t, string(revealed), `"revealed_at":"2026-10-19T12:00:00Z"`)
+
+}
+
+func TestVoteRequestInvalid(t *testing.T) {
+
+	body := VoteRequest{
+		PlayerId: " ",
+	}
+	assert.EqualError(t, body.Validate(), "the player is required; the card is required")
+
+	body = VoteRequest{PlayerId: "1", Card: "5", Confidence: 6}
+	assert.EqualError(t, body.Validate(), "the confidence must be from 1 to 5")
+
+}
+
+func TestNewSummaryConfidence(t *testing.T) {
+
+	pls := []players.Player{{Id: "1", Name: "Ana"}, {Id: "2", Name: "Bob"}}
+	round := Round{
+		Confidence:  true,
+		Votes:       map[string]string{"1": "3", "2": "5"},
+		Confidences: map[string]int{"1": 4, "2": 1},
+	}
+
+	summary := NewSummary(round, "", pls)
+
+	assert.Equal(t, 4, summary.Votes[0].Confidence)
+	assert.Equal(t, map[string]int{"1": 1, "4": 1}, summary.Confidence.Distribution)
+	assert.Equal(t, 2.5, *summary.Confidence.Average)
+	assert.Equal(t, 1, summary.Confidence.Lowest)
+
+	assert.Nil(t, NewSummary(Round{Votes: round.Votes}, "", pls).Confidence)
+
+}
+
+func TestNewSummaryAnonymousConfidence(t *testing.T) {
+
+	round := Round{
+		Anonymous:  true,
+		Revealed:   true,
+		Confidence: true,
+	}
+	round.VoteAnonymously("1", "3", 2)
+	round.VoteAnonymously("2", "5", 5)
+
+	summary := NewSummary(round, "", nil)
+
+	assert.Empty(t, summary.Votes)
+	assert.Equal(t, map[string]int{"2": 1, "5": 1}, summary.Confidence.Distribution)
+	assert.Equal(t, 3.5, *summary.Confidence.Average)
+
+}
@@ -0,0 +1,44 @@
+package stories
+
+import (
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/validation"
+	"time"
+)
+
+const MaxEstimateLength = 16
+
+type Story struct {
+	Id          string    `json:"id"`
+	Key         string    `json:"key" firestore:"key"`
+	Title       string    `json:"title" firestore:"title"`
+	Link        string    `json:"link" firestore:"link"`
+	Description string    `json:"description" firestore:"description"`
+	Estimate    string    `json:"estimate" firestore:"estimate"`
+	CreatedAt   time.Time `json:"created_at" firestore:"timestamp"`
+}
+
+type StoryImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type StoryImportResponse struct {
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Errors  []StoryImportError `json:"errors"`
+}
+
+// StoryEstimateRequest sets the final estimate of a story, which is not
+// necessarily one of the cards voted.
+type StoryEstimateRequest struct {
+	Estimate string `json:"estimate"`
+}
+
+func (body *StoryEstimateRequest) Validate() error {
+	v := validation.New()
+	v.Text("estimate", "the estimate", &body.Estimate,
+		validation.Required, validation.MaxLength(MaxEstimateLength), validation.NoControl)
+
+	return v.Err()
+}
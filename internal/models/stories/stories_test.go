@@ -0,0 +1,29 @@
+package stories
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestStoryEstimateRequestValid(t *testing.T) {
+
+	body := StoryEstimateRequest{
+		Estimate: " 5 ",
+	}
+	assert.NoError(t, body.Validate())
+	assert.Equal(t, "5", body.Estimate)
+
+}
+
+func TestStoryEstimateRequestInvalid(t *testing.T) {
+
+	body := StoryEstimateRequest{}
+	assert.EqualError(t, body.Validate(), "the estimate is required")
+
+	body = StoryEstimateRequest{
+		Estimate: strings.Repeat("8", MaxEstimateLength+1),
+	}
+	assert.EqualError(t, body.Validate(), "the estimate must have at most 16 characters")
+
+}
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/validation"
+	"time"
+)
+
+const (
+	EventRoomCreated    = "room.created"
+	EventPlayerJoined   = "player.joined"
+	EventRoundStarted   = "round.started"
+	EventVoteCast       = "vote.cast"
+	EventRoundRevealed  = "round.revealed"
+	EventStoryEstimated = "story.estimated"
+	EventPollOpened     = "poll.opened"
+	EventPollVoted      = "poll.voted"
+	EventPollClosed     = "poll.closed"
+)
+
+var Events = []string{EventRoomCreated, EventPlayerJoined, EventRoundStarted, EventVoteCast, EventRoundRevealed, EventStoryEstimated,
+	EventPollOpened, EventPollVoted, EventPollClosed}
+
+const MaxUrlLength = 2048
+
+type Webhook struct {
+	Id        string    `json:"id"`
+	Url       string    `json:"url" firestore:"url"`
+	Events    []string  `json:"events" firestore:"events"`
+	Secret    string    `json:"-" firestore:"secret"`
+	CreatedAt time.Time `json:"created_at" firestore:"timestamp"`
+}
+
+// Accepts tells whether the webhook subscribed to the event. A webhook without
+// events receives all of them.
+func (w Webhook) Accepts(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookNewRequest struct {
+	Url    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+func (body *WebhookNewRequest) Validate() error {
+	v := validation.New()
+	v.Text("url", "the url of the webhook", &body.Url,
+		validation.Required, validation.MaxLength(MaxUrlLength), validation.HttpUrl)
+	for i := range body.Events {
+		v.Text(fmt.Sprintf("events[%d]", i), "the event", &body.Events[i], validation.OneOf(Events...))
+	}
+
+	return v.Err()
+}
+
+type WebhookNewResponse struct {
+	Webhook
+	// The secret used to sign the payloads. It is only shown once.
+	Secret string `json:"secret"`
+}
+
+type Delivery struct {
+	Id          string    `json:"id"`
+	WebhookId   string    `json:"webhook_id" firestore:"webhook_id"`
+	Event       string    `json:"event" firestore:"event"`
+	Url         string    `json:"url" firestore:"url"`
+	Attempts    int       `json:"attempts" firestore:"attempts"`
+	StatusCode  int       `json:"status_code" firestore:"status_code"`
+	Error       string    `json:"error,omitempty" firestore:"error"`
+	Success     bool      `json:"success" firestore:"success"`
+	DeliveredAt time.Time `json:"delivered_at" firestore:"timestamp"`
+}
@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"testing"
+)
+
+func TestWebhookNewRequestValid(t *testing.T) {
+
+	body := WebhookNewRequest{
+		Url:    " https://example.com/hooks ",
+		Events: []string{EventRoundRevealed},
+	}
+	assert.NoError(t, body.Validate())
+	assert.Equal(t, "https://example.com/hooks", body.Url)
+
+}
+
+func TestWebhookNewRequestUrlIsEmpty(t *testing.T) {
+
+	body := WebhookNewRequest{
+		Url: "",
+	}
+	assert.EqualError(t, body.Validate(), "the url of the webhook is required")
+
+}
+
+func TestWebhookNewRequestUrlIsInvalid(t *testing.T) {
+
+	body := WebhookNewRequest{
+		Url: "ftp://example.com",
+	}
+	assert.EqualError(t, body.Validate(), "the url of the webhook must be an http(s) URL")
+
+}
+
+func TestWebhookNewRequestUnknownEvent(t *testing.T) {
+
+	body := WebhookNewRequest{
+		Url:    "https://example.com/hooks",
+		Events: []string{"room.deleted"},
+	}
+	assert.EqualError(t, body.Validate(), "the event must be one of room.created, player.joined, round.started, vote.cast, round.revealed, story.estimated, poll.opened, poll.voted, poll.closed")
+
+}
+
+func TestWebhookNewRequestReportsAllFields(t *testing.T) {
+
+	body := WebhookNewRequest{
+		Url:    "ftp://example.com",
+		Events: []string{EventPlayerJoined, "room.deleted"},
+	}
+	err := body.Validate()
+
+	e := new(apierror.Error)
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, []models.FieldError{
+			{Field: "url", Message: "the url of the webhook must be an http(s) URL"},
+			{Field: "events[1]", Message: "the event must be one of room.created, player.joined, round.started, vote.cast, round.revealed, story.estimated, poll.opened, poll.voted, poll.closed"},
+		}, e.Fields)
+	}
+
+}
+
+func TestWebhookAccepts(t *testing.T) {
+
+	assert.True(t, Webhook{}.Accepts(EventPlayerJoined))
+	assert.True(t, Webhook{Events: []string{EventPlayerJoined}}.Accepts(EventPlayerJoined))
+	assert.False(t, Webhook{Events: []string{EventPlayerJoined}}.Accepts(EventRoomCreated))
+
+}
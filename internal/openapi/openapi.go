@@ -0,0 +1,447 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/polls"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification of the documents.
+const Version = "3.1.0"
+
+type object = map[string]interface{}
+
+// The descriptions of the security schemes, which swag can't annotate
+var securityDescriptions = map[string]string{
+	"FacilitatorToken": "The facilitator_token returned when the room is created.",
+	"PlayerToken":      "The player_token returned when the player joins the room.",
+}
+
+// EventData tells the type of the data of each event. The events without
+// one have no known data.
+var EventData = map[string]interface{}{
+	webhooks.EventRoomCreated:    rooms.Room{},
+	webhooks.EventPlayerJoined:   players.Player{},
+	webhooks.EventRoundStarted:   rounds.Round{},
+	webhooks.EventVoteCast:       rounds.Ballot{},
+	webhooks.EventRoundRevealed:  rounds.Summary{},
+	webhooks.EventStoryEstimated: stories.Story{},
+	webhooks.EventPollOpened:     polls.Poll{},
+	webhooks.EventPollVoted:      polls.PollBallot{},
+	webhooks.EventPollClosed:     polls.Poll{},
+}
+
+// Convert turns the Swagger 2.0 document that swag generates from the
+// annotations of the handlers into an OpenAPI 3.1 document. It adds what the
+// annotations can't tell: the bearer tokens, the codes of the errors, the
+// problem details and the events of the rooms.
+func Convert(swagger []byte) (map[string]interface{}, error) {
+	var doc object
+	if err := json.Unmarshal(swagger, &doc); err != nil {
+		return nil, err
+	}
+	if doc["swagger"] != "2.0" {
+		return nil, errors.New("the document is not Swagger 2.0")
+	}
+	doc = rewriteRefs(doc).(object)
+
+	schemas := asObject(doc["definitions"])
+	if schemas == nil {
+		schemas = object{}
+	}
+
+	out := object{
+		"openapi": Version,
+		"info":    doc["info"],
+		"servers": []interface{}{object{"url": "/"}},
+		"paths":   paths(doc),
+		"components": object{
+			"schemas":         schemas,
+			"securitySchemes": securitySchemes(doc),
+		},
+	}
+
+	if err := addErrors(out, schemas); err != nil {
+		return nil, err
+	}
+	addEvents(out, schemas)
+
+	return out, nil
+}
+
+// rewriteRefs points the references to the definitions to the components.
+func rewriteRefs(value interface{}) interface{} {
+	switch v := value.(type) {
+	case object:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				v[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				continue
+			}
+			v[key] = rewriteRefs(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = rewriteRefs(child)
+		}
+	}
+	return value
+}
+
+func asObject(value interface{}) object {
+	o, _ := value.(object)
+	return o
+}
+
+func asStrings(value interface{}) []string {
+	list, _ := value.([]interface{})
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+func copyKeys(dst, src object, keys ...string) {
+	for _, key := range keys {
+		if value, ok := src[key]; ok {
+			dst[key] = value
+		}
+	}
+}
+
+func paths(doc object) object {
+	consumes := asStrings(doc["consumes"])
+	produces := asStrings(doc["produces"])
+
+	out := object{}
+	for path, item := range asObject(doc["paths"]) {
+		ops := object{}
+		for method, op := range asObject(item) {
+			ops[method] = operation(asObject(op), consumes, produces)
+		}
+		out[path] = ops
+	}
+	return out
+}
+
+func operation(op object, consumes, produces []string) object {
+	if c := asStrings(op["consumes"]); len(c) > 0 {
+		consumes = c
+	}
+	if p := asStrings(op["produces"]); len(p) > 0 {
+		produces = p
+	}
+
+	out := object{}
+	copyKeys(out, op, "tags", "summary", "description", "operationId", "deprecated", "security")
+
+	params := make([]interface{}, 0)
+	form := object{}
+	required := make([]interface{}, 0)
+	list, _ := op["parameters"].([]interface{})
+	for _, param := range list {
+		p := asObject(param)
+		switch p["in"] {
+		case "body":
+			body := object{"content": content(consumes, p["schema"])}
+			copyKeys(body, p, "description", "required")
+			out["requestBody"] = body
+		case "formData":
+			form[p["name"].(string)] = paramSchema(p)
+			if p["required"] == true {
+				required = append(required, p["name"])
+			}
+		default:
+			converted := object{"schema": paramSchema(p)}
+			copyKeys(converted, p, "name", "in", "description", "required")
+			params = append(params, converted)
+		}
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if _, ok := out["requestBody"]; !ok && len(consumes) > 0 {
+		// The form fields only describe the form media types, the other
+		// ones are sent as they are
+		body := object{}
+		for _, mime := range consumes {
+			schema := object{}
+			if isForm(mime) {
+				schema = object{"type": "object", "properties": form}
+				if len(required) > 0 {
+					schema["required"] = required
+				}
+			}
+			body[mime] = object{"schema": schema}
+		}
+		out["requestBody"] = object{"content": body}
+	}
+
+	responses := object{}
+	for code, response := range asObject(op["responses"]) {
+		r := asObject(response)
+		converted := object{"description": r["description"]}
+		if converted["description"] == nil || converted["description"] == "" {
+			converted["description"] = statusText(code)
+		}
+		if schema, ok := r["schema"]; ok {
+			if asObject(schema)["type"] == "file" {
+				schema = object{"type": "string", "format": "binary"}
+			}
+			converted["content"] = content(produces, schema)
+		}
+		if headers := asObject(r["headers"]); len(headers) > 0 {
+			converted["headers"] = responseHeaders(headers)
+		}
+		responses[code] = converted
+	}
+	out["responses"] = responses
+
+	return out
+}
+
+func isForm(mime string) bool {
+	return mime == "multipart/form-data" || mime == "application/x-www-form-urlencoded"
+}
+
+func statusText(code string) string {
+	var status int
+	if _, err := fmt.Sscanf(code, "%d", &status); err == nil {
+		if text := http.StatusText(status); len(text) > 0 {
+			return text
+		}
+	}
+	return code
+}
+
+func content(mimes []string, schema interface{}) object {
+	if len(mimes) == 0 {
+		mimes = []string{"application/json"}
+	}
+	out := object{}
+	for _, mime := range mimes {
+		out[mime] = object{"schema": schema}
+	}
+	return out
+}
+
+// responseHeaders moves the types of the headers of a response to schemas.
+func responseHeaders(headers object) object {
+	out := object{}
+	for name, header := range headers {
+		h := asObject(header)
+		converted := object{"schema": paramSchema(h)}
+		copyKeys(converted, h, "description")
+		out[name] = converted
+	}
+	return out
+}
+
+// paramSchema moves the type of a Swagger 2.0 parameter to a schema.
+func paramSchema(p object) object {
+	schema := object{}
+	copyKeys(schema, p, "type", "format", "enum", "default", "items", "minimum", "maximum", "pattern")
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	return schema
+}
+
+// securitySchemes turns the API keys sent in Authorization, which is how
+// Swagger 2.0 describes them, into bearer tokens.
+func securitySchemes(doc object) object {
+	out := object{}
+	for name, definition := range asObject(doc["securityDefinitions"]) {
+		d := asObject(definition)
+		scheme := object{}
+		if d["type"] == "apiKey" && d["in"] == "header" && d["name"] == "Authorization" {
+			scheme["type"] = "http"
+			scheme["scheme"] = "bearer"
+		} else {
+			copyKeys(scheme, d, "type", "name", "in")
+		}
+		if description, ok := securityDescriptions[name]; ok {
+			scheme["description"] = description
+		}
+		out[name] = scheme
+	}
+	return out
+}
+
+// addErrors lists the codes of the errors and offers the errors as problem
+// details too, as the clients that ask for them get.
+func addErrors(doc, schemas object) error {
+	errorSchema := asObject(schemas["models.Error"])
+	if errorSchema == nil {
+		return errors.New("the document has no models.Error")
+	}
+
+	codes := apierror.Codes()
+	enum := make([]interface{}, len(codes))
+	described := make([]string, len(codes))
+	for i, code := range codes {
+		enum[i] = string(code)
+		described[i] = fmt.Sprintf("%s (%d)", code, code.Status())
+	}
+	errorCode := object{
+		"type":        "string",
+		"enum":        enum,
+		"description": "Stable code of the error, with the status that answers it: " + strings.Join(described, ", "),
+	}
+	asObject(errorSchema["properties"])["error"] = errorCode
+
+	schemas["models.Problem"] = schemaOf(reflect.TypeOf(models.Problem{}), schemas)
+	asObject(asObject(schemas["models.Problem"])["properties"])["code"] = errorCode
+
+	problem := object{"schema": object{"$ref": "#/components/schemas/models.Problem"}}
+	for _, item := range asObject(doc["paths"]) {
+		for _, op := range asObject(item) {
+			for _, response := range asObject(asObject(op)["responses"]) {
+				c := asObject(asObject(response)["content"])
+				if body, ok := c["application/json"]; ok && asObject(asObject(body)["schema"])["$ref"] == "#/components/schemas/models.Error" {
+					c["application/problem+json"] = problem
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addEvents describes the events of the rooms, both as the webhooks of the
+// document and as the schemas of the stream of events.
+func addEvents(doc, schemas object) {
+	names := append([]string{}, webhooks.Events...)
+	sort.Strings(names)
+
+	hooks := object{}
+	refs := make([]interface{}, 0, len(names))
+	mapping := object{}
+	for _, name := range names {
+		data := object{}
+		if sample, ok := EventData[name]; ok {
+			data = schemaRef(reflect.TypeOf(sample), schemas)
+		}
+
+		schemaName := "events." + name
+		schemas[schemaName] = object{
+			"type":     "object",
+			"required": []interface{}{"id", "event", "room_id", "pincode", "timestamp", "data"},
+			"properties": object{
+				"id":        object{"type": "string"},
+				"event":     object{"const": name},
+				"room_id":   object{"type": "string"},
+				"pincode":   object{"type": "string"},
+				"timestamp": object{"type": "string", "format": "date-time"},
+				"data":      data,
+			},
+		}
+
+		ref := "#/components/schemas/" + schemaName
+		refs = append(refs, object{"$ref": ref})
+		mapping[name] = ref
+
+		hooks[name] = object{
+			"post": object{
+				"summary": fmt.Sprintf("The %s event", name),
+				"parameters": []interface{}{
+					object{"name": "X-ScrumPoker-Event", "in": "header", "required": true, "schema": object{"const": name}},
+					object{"name": "X-ScrumPoker-Delivery", "in": "header", "required": true, "schema": object{"type": "string"}},
+					object{"name": "X-ScrumPoker-Timestamp", "in": "header", "required": true, "schema": object{"type": "string"}},
+					object{"name": "X-ScrumPoker-Signature", "in": "header", "description": "sha256= and the HMAC-SHA256 of the timestamp, a dot and the body, with the secret of the webhook", "schema": object{"type": "string"}},
+				},
+				"requestBody": object{"required": true, "content": object{"application/json": object{"schema": object{"$ref": ref}}}},
+				"responses":   object{"2XX": object{"description": "The event was received"}},
+			},
+		}
+	}
+
+	schemas["events.Event"] = object{
+		"oneOf":         refs,
+		"discriminator": object{"propertyName": "event", "mapping": mapping},
+	}
+	doc["webhooks"] = hooks
+}
+
+// schemaRef refers to the schema of a struct, adding it to the schemas when
+// swag didn't. Other types are described inline.
+func schemaRef(t reflect.Type, schemas object) object {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() == "time" {
+		return schemaOf(t, schemas)
+	}
+
+	name := t.String()
+	if _, ok := schemas[name]; !ok {
+		schemas[name] = object{}
+		schemas[name] = schemaOf(t, schemas)
+	}
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaOf describes a type the way encoding/json marshals it.
+func schemaOf(t reflect.Type, schemas object) object {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			return object{"type": "string", "format": "date-time"}
+		}
+		properties := object{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" || field.PkgPath != "" {
+				continue
+			}
+			if len(name) == 0 && field.Anonymous && field.Type.Kind() == reflect.Struct {
+				// Embedded structs are flattened
+				for key, value := range asObject(schemaOf(field.Type, schemas)["properties"]) {
+					properties[key] = value
+				}
+				continue
+			}
+			if len(name) == 0 {
+				name = field.Name
+			}
+			properties[name] = schemaRef(field.Type, schemas)
+		}
+		return object{"type": "object", "properties": properties}
+	}
+	return object{}
+}
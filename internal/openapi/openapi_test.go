@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const swagger = `{
+	"swagger": "2.0",
+	"info": {"title": "Scrum Poker API", "version": "1.0"},
+	"paths": {
+		"/v1/rooms/{pincode}/rounds": {
+			"post": {
+				"consumes": ["application/json"],
+				"produces": ["application/json"],
+				"security": [{"FacilitatorToken": []}],
+				"parameters": [
+					{"type": "string", "description": "Pin Code of the Room", "name": "pincode", "in": "path", "required": true},
+					{"description": "Story of the round", "name": "body", "in": "body", "schema": {"$ref": "#/definitions/rounds.RoundNewRequest"}}
+				],
+				"responses": {
+					"201": {"description": "Created", "schema": {"$ref": "#/definitions/rounds.Round"}, "headers": {"ETag": {"type": "string", "description": "Version of the room"}}},
+					"404": {"description": "", "schema": {"$ref": "#/definitions/models.Error"}}
+				}
+			}
+		},
+		"/v1/rooms/{pincode}/stories/import": {
+			"post": {
+				"consumes": ["multipart/form-data", "text/csv"],
+				"parameters": [
+					{"type": "file", "name": "file", "in": "formData", "required": true}
+				],
+				"responses": {"200": {"description": "OK"}}
+			}
+		}
+	},
+	"definitions": {
+		"models.Error": {"type": "object", "properties": {"code": {"type": "integer"}, "error": {"type": "string"}, "message": {"type": "string"}}},
+		"rounds.Round": {"type": "object", "properties": {"number": {"type": "integer"}}},
+		"rounds.RoundNewRequest": {"type": "object", "properties": {"story_id": {"type": "string"}}}
+	},
+	"securityDefinitions": {
+		"FacilitatorToken": {"type": "apiKey", "name": "Authorization", "in": "header"}
+	}
+}`
+
+// at walks the document through the keys
+func at(doc interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		doc = doc.(map[string]interface{})[key]
+	}
+	return doc
+}
+
+func convert(assert *Assert.Assertions) map[string]interface{} {
+	doc, err := Convert([]byte(swagger))
+	assert.NoError(err)
+
+	// As served
+	data, err := json.Marshal(doc)
+	assert.NoError(err)
+	out := make(map[string]interface{})
+	assert.NoError(json.Unmarshal(data, &out))
+	return out
+}
+
+func TestConvertOperations(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	doc := convert(assert)
+	assert.Equal("3.1.0", doc["openapi"])
+	assert.Equal("Scrum Poker API", at(doc, "info", "title"))
+
+	op := at(doc, "paths", "/v1/rooms/{pincode}/rounds", "post")
+	assert.Equal([]interface{}{map[string]interface{}{"FacilitatorToken": []interface{}{}}}, at(op, "security"))
+	assert.Equal([]interface{}{map[string]interface{}{
+		"name":        "pincode",
+		"in":          "path",
+		"description": "Pin Code of the Room",
+		"required":    true,
+		"schema":      map[string]interface{}{"type": "string"},
+	}}, at(op, "parameters"))
+	assert.Equal("#/components/schemas/rounds.RoundNewRequest", at(op, "requestBody", "content", "application/json", "schema", "$ref"))
+	assert.Equal("#/components/schemas/rounds.Round", at(op, "responses", "201", "content", "application/json", "schema", "$ref"))
+	assert.Equal(map[string]interface{}{"description": "Version of the room", "schema": map[string]interface{}{"type": "string"}}, at(op, "responses", "201", "headers", "ETag"))
+	assert.Equal("Not Found", at(op, "responses", "404", "description"))
+	assert.Equal("#/components/schemas/models.Problem", at(op, "responses", "404", "content", "application/problem+json", "schema", "$ref"))
+}
+
+func TestConvertForm(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	doc := convert(assert)
+
+	body := at(doc, "paths", "/v1/rooms/{pincode}/stories/import", "post", "requestBody", "content")
+	assert.Equal(map[string]interface{}{"type": "string", "format": "binary"}, at(body, "multipart/form-data", "schema", "properties", "file"))
+	assert.Equal([]interface{}{"file"}, at(body, "multipart/form-data", "schema", "required"))
+	assert.Equal(map[string]interface{}{}, at(body, "text/csv", "schema"))
+}
+
+func TestConvertSecuritySchemes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	doc := convert(assert)
+
+	assert.Equal(map[string]interface{}{
+		"type":        "http",
+		"scheme":      "bearer",
+		"description": "The facilitator_token returned when the room is created.",
+	}, at(doc, "components", "securitySchemes", "FacilitatorToken"))
+}
+
+func TestConvertErrorCodes(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	doc := convert(assert)
+
+	code := at(doc, "components", "schemas", "models.Error", "properties", "error")
+	assert.Contains(at(code, "enum"), "room_not_found")
+	assert.Contains(at(code, "description"), "room_not_found (404)")
+	assert.Equal(code, at(doc, "components", "schemas", "models.Problem", "properties", "code"))
+}
+
+func TestConvertEvents(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	doc := convert(assert)
+
+	schemas := at(doc, "components", "schemas")
+	assert.Equal(map[string]interface{}{"const": "vote.cast"}, at(schemas, "events.vote.cast", "properties", "event"))
+	assert.Equal("#/components/schemas/rounds.Ballot", at(schemas, "events.vote.cast", "properties", "data", "$ref"))
+	assert.Equal(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"round":     map[string]interface{}{"type": "integer"},
+			"player_id": map[string]interface{}{"type": "string"},
+		},
+	}, at(schemas, "rounds.Ballot"))
+	assert.Equal(map[string]interface{}{"type": "object", "properties": map[string]interface{}{"number": map[string]interface{}{"type": "integer"}}},
+		at(schemas, "rounds.Round"), "the schemas of swag are kept")
+	assert.Len(at(schemas, "events.Event", "oneOf"), 9)
+
+	hook := at(doc, "webhooks", "round.revealed", "post")
+	assert.Equal("#/components/schemas/events.round.revealed", at(hook, "requestBody", "content", "application/json", "schema", "$ref"))
+}
+
+func TestConvertNotSwagger(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := Convert([]byte(`{"openapi": "3.0.0"}`))
+	assert.EqualError(err, "the document is not Swagger 2.0")
+
+	_, err = Convert([]byte(`{"swagger": "2.0"}`))
+	assert.EqualError(err, "the document has no models.Error")
+}
@@ -0,0 +1,367 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUndocumented tells that the document has no operation for a request.
+var ErrUndocumented = errors.New("the operation is not documented")
+
+// ValidationError lists how a request or a response breaks the document.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validator checks requests and responses against an OpenAPI 3.1 document,
+// as built by Convert. It knows the parts of JSON Schema the document uses.
+type Validator struct {
+	schemas    object
+	operations []operationPath
+}
+
+type operationPath struct {
+	method   string
+	segments []string
+	op       object
+}
+
+// NewValidator reads the OpenAPI document to validate against.
+func NewValidator(document []byte) (*Validator, error) {
+	var doc object
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, err
+	}
+	if doc["openapi"] != Version {
+		return nil, fmt.Errorf("the document is not OpenAPI %s", Version)
+	}
+
+	v := &Validator{
+		schemas: asObject(asObject(doc["components"])["schemas"]),
+	}
+	for path, item := range asObject(doc["paths"]) {
+		for method, op := range asObject(item) {
+			v.operations = append(v.operations, operationPath{
+				method:   strings.ToUpper(method),
+				segments: strings.Split(path, "/"),
+				op:       asObject(op),
+			})
+		}
+	}
+
+	// The literal segments win over the parameters, e.g. webhooks/deliveries
+	// over webhooks/{id}
+	sort.SliceStable(v.operations, func(i, j int) bool {
+		return literals(v.operations[i].segments) > literals(v.operations[j].segments)
+	})
+
+	return v, nil
+}
+
+func literals(segments []string) int {
+	n := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+// find returns the operation of a request and the values of its path
+// parameters.
+func (v *Validator) find(method, path string) (object, map[string]string, error) {
+	if method == "HEAD" {
+		method = "GET"
+	}
+	segments := strings.Split(path, "/")
+
+next:
+	for _, o := range v.operations {
+		if o.method != method || len(o.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		for i, segment := range o.segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				if len(segments[i]) == 0 {
+					continue next
+				}
+				value, _ := url.PathUnescape(segments[i])
+				params[segment[1:len(segment)-1]] = value
+			} else if segment != segments[i] {
+				continue next
+			}
+		}
+		return o.op, params, nil
+	}
+	return nil, nil, ErrUndocumented
+}
+
+// ValidateRequest checks the parameters and the body of a request. It
+// returns ErrUndocumented when the document has no operation for it, or a
+// *ValidationError.
+func (v *Validator) ValidateRequest(method, path string, query url.Values, contentType string, body []byte) error {
+	op, params, err := v.find(method, path)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	list, _ := op["parameters"].([]interface{})
+	for _, param := range list {
+		p := asObject(param)
+		name, _ := p["name"].(string)
+
+		var value string
+		var present bool
+		switch p["in"] {
+		case "path":
+			value, present = params[name]
+		case "query":
+			if values, ok := query[name]; ok && len(values) > 0 {
+				value, present = values[0], true
+			}
+		default:
+			// The headers are up to the middlewares, e.g. the tokens
+			continue
+		}
+
+		if !present {
+			if p["required"] == true {
+				problems = append(problems, fmt.Sprintf("the %s parameter %s is missing", p["in"], name))
+			}
+			continue
+		}
+		v.validate(asObject(p["schema"]), parseParam(asObject(p["schema"]), value), fmt.Sprintf("the %s parameter %s", p["in"], name), &problems)
+	}
+
+	if requestBody := asObject(op["requestBody"]); requestBody != nil && (len(body) > 0 || requestBody["required"] == true) {
+		v.validateContent(asObject(requestBody["content"]), contentType, body, "the request body", &problems)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateResponse checks the status, the content type and the body of the
+// response to a request. It returns ErrUndocumented when the document has no
+// operation for the request, or a *ValidationError.
+func (v *Validator) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op, _, err := v.find(method, path)
+	if err != nil {
+		return err
+	}
+
+	responses := asObject(op["responses"])
+	code := strconv.Itoa(status)
+	response, ok := responses[code]
+	if !ok {
+		response, ok = responses[code[:1]+"XX"]
+	}
+	if !ok {
+		response, ok = responses["default"]
+	}
+	if !ok {
+		return &ValidationError{Problems: []string{fmt.Sprintf("the status %d is not documented", status)}}
+	}
+
+	var problems []string
+	content := asObject(asObject(response)["content"])
+	switch {
+	case method == "HEAD":
+		// Answered without a body
+	case content == nil && len(body) > 0:
+		problems = append(problems, fmt.Sprintf("the response %d has a body, but none is documented", status))
+	case content != nil:
+		v.validateContent(content, contentType, body, "the response body", &problems)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateContent checks that the media type of a body is documented and,
+// when it is JSON, that the body matches its schema.
+func (v *Validator) validateContent(content object, contentType string, body []byte, at string, problems *[]string) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s has no valid content type: %q", at, contentType))
+		return
+	}
+	media, ok := content[mediaType]
+	if !ok {
+		*problems = append(*problems, fmt.Sprintf("%s is %s, which is not documented", at, mediaType))
+		return
+	}
+	if !strings.HasSuffix(mediaType, "json") {
+		return
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s is not valid JSON: %s", at, err))
+		return
+	}
+	v.validate(asObject(asObject(media)["schema"]), value, at, problems)
+}
+
+// parseParam reads a parameter as the type of its schema, leaving it as it
+// is when it isn't one.
+func parseParam(schema object, value string) interface{} {
+	switch schema["type"] {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validate checks a value decoded by encoding/json against a schema. The
+// objects are closed: a property that is not documented is a problem, as it
+// is most likely a renamed one.
+func (v *Validator) validate(schema object, value interface{}, at string, problems *[]string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := v.schemas[name]
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s refers to the unknown schema %s", at, ref))
+			return
+		}
+		schema = asObject(resolved)
+	}
+
+	if list, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, option := range list {
+			var optionProblems []string
+			v.validate(asObject(option), value, at, &optionProblems)
+			if len(optionProblems) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			*problems = append(*problems, fmt.Sprintf("%s matches %d of the schemas of oneOf", at, matches))
+		}
+	}
+
+	if expected, ok := schema["const"]; ok && !reflect.DeepEqual(expected, value) {
+		*problems = append(*problems, fmt.Sprintf("%s is %v instead of %v", at, value, expected))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if reflect.DeepEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s is %v, which is not one of %v", at, value, enum))
+		}
+	}
+
+	kind, _ := schema["type"].(string)
+	if len(kind) == 0 {
+		return
+	}
+	if value == nil && (kind == "array" || kind == "object") {
+		// encoding/json writes the nil slices and maps as null
+		return
+	}
+
+	switch kind {
+	case "object":
+		o, ok := value.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not an object", at))
+			return
+		}
+		v.validateObject(schema, o, at, problems)
+	case "array":
+		list, ok := value.([]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not an array", at))
+			return
+		}
+		if items := asObject(schema["items"]); items != nil {
+			for i, item := range list {
+				v.validate(items, item, fmt.Sprintf("%s[%d]", at, i), problems)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not a string", at))
+			return
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s is not a date-time: %q", at, str))
+			}
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			*problems = append(*problems, fmt.Sprintf("%s is not an integer", at))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not a number", at))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not a boolean", at))
+		}
+	}
+}
+
+func (v *Validator) validateObject(schema, value object, at string, problems *[]string) {
+	properties := asObject(schema["properties"])
+	for _, name := range asStrings(schema["required"]) {
+		if _, ok := value[name]; !ok {
+			*problems = append(*problems, fmt.Sprintf("%s has no %s", at, name))
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	additional, hasAdditional := schema["additionalProperties"]
+	for _, name := range names {
+		if property, ok := properties[name]; ok {
+			v.validate(asObject(property), value[name], at+"."+name, problems)
+			continue
+		}
+		switch {
+		case hasAdditional && additional != false:
+			v.validate(asObject(additional), value[name], at+"."+name, problems)
+		case hasAdditional || properties != nil:
+			*problems = append(*problems, fmt.Sprintf("%s has %s, which is not documented", at, name))
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package openapi
+
+import (
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func newValidator(assert *Assert.Assertions) *Validator {
+	doc, err := Convert([]byte(swagger))
+	assert.NoError(err)
+	document, err := json.Marshal(doc)
+	assert.NoError(err)
+
+	validator, err := NewValidator(document)
+	assert.NoError(err)
+	return validator
+}
+
+func problems(err error) []string {
+	if e, ok := err.(*ValidationError); ok {
+		return e.Problems
+	}
+	return nil
+}
+
+func TestValidateRequest(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	validator := newValidator(assert)
+
+	assert.NoError(validator.ValidateRequest("POST", "/v1/rooms/123456/rounds", nil, "application/json", []byte(`{"story_id": "story-1"}`)))
+	assert.NoError(validator.ValidateRequest("POST", "/v1/rooms/123456/rounds", nil, "", nil), "the body is optional")
+
+	err := validator.ValidateRequest("POST", "/v1/rooms/123456/rounds", nil, "application/json", []byte(`{"story": "story-1"}`))
+	assert.Equal([]string{"the request body has story, which is not documented"}, problems(err))
+
+	err = validator.ValidateRequest("POST", "/v1/rooms/123456/rounds", nil, "text/plain", []byte(`story-1`))
+	assert.Equal([]string{"the request body is text/plain, which is not documented"}, problems(err))
+
+	err = validator.ValidateRequest("POST", "/v1/rooms/123456/rounds", url.Values{}, "application/json", []byte(`{"story_id": 1}`))
+	assert.Equal([]string{"the request body.story_id is not a string"}, problems(err))
+
+	assert.NoError(validator.ValidateRequest("POST", "/v1/rooms/123456/stories/import", nil, "multipart/form-data; boundary=x", []byte("--x--")))
+
+	assert.Equal(ErrUndocumented, validator.ValidateRequest("GET", "/v1/rooms/123456/rounds", nil, "", nil))
+	assert.Equal(ErrUndocumented, validator.ValidateRequest("POST", "/v1/rooms//rounds", nil, "", nil))
+}
+
+func TestValidateResponse(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	validator := newValidator(assert)
+
+	assert.NoError(validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 201, "application/json", []byte(`{"number": 1}`)))
+	assert.NoError(validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 404, "application/json",
+		[]byte(`{"code": 404, "error": "room_not_found", "message": "room not found"}`)))
+	assert.NoError(validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 404, "application/problem+json",
+		[]byte(`{"type": "about:blank", "title": "Not Found", "status": 404, "detail": "room not found", "code": "room_not_found"}`)))
+
+	err := validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 201, "application/json", []byte(`{"round": 1}`))
+	assert.Equal([]string{"the response body has round, which is not documented"}, problems(err))
+
+	err = validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 201, "application/json", []byte(`{"number": 1.5}`))
+	assert.Equal([]string{"the response body.number is not an integer"}, problems(err))
+
+	err = validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 404, "application/json",
+		[]byte(`{"code": 404, "error": "not_a_code", "message": "room not found"}`))
+	assert.Len(problems(err), 1)
+	assert.Contains(problems(err)[0], "the response body.error is not_a_code, which is not one of")
+
+	err = validator.ValidateResponse("POST", "/v1/rooms/123456/rounds", 409, "application/json", []byte(`{}`))
+	assert.Equal([]string{"the status 409 is not documented"}, problems(err))
+
+	err = validator.ValidateResponse("POST", "/v1/rooms/123456/stories/import", 200, "text/plain", []byte(`imported`))
+	assert.Equal([]string{"the response 200 has a body, but none is documented"}, problems(err))
+}
+
+func TestValidateEvents(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	validator := newValidator(assert)
+
+	var problems []string
+	event := map[string]interface{}{
+		"id":        "event-1",
+		"event":     "vote.cast",
+		"room_id":   "room-1",
+		"pincode":   "123456",
+		"timestamp": "2021-03-01T10:00:00Z",
+		"data":      map[string]interface{}{"round": 1.0, "player_id": "player-1"},
+	}
+	validator.validate(object{"$ref": "#/components/schemas/events.Event"}, event, "the event", &problems)
+	assert.Empty(problems)
+
+	event["timestamp"] = "yesterday"
+	event["data"] = map[string]interface{}{"round": "1"}
+	validator.validate(object{"$ref": "#/components/schemas/events.vote.cast"}, event, "the event", &problems)
+	assert.Equal([]string{
+		"the event.data.round is not an integer",
+		"the event.timestamp is not a date-time: \"yesterday\"",
+	}, problems)
+}
+
+func TestValidateNull(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	validator := newValidator(assert)
+
+	var problems []string
+	validator.validate(object{"type": "array"}, nil, "the votes", &problems)
+	validator.validate(object{"type": "object"}, nil, "the distribution", &problems)
+	assert.Empty(problems, "as encoding/json writes nil slices and maps")
+
+	validator.validate(object{"type": "string"}, nil, "the name", &problems)
+	assert.Equal([]string{"the name is not a string"}, problems)
+}
+
+func TestNewValidatorNotOpenApi(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := NewValidator([]byte(swagger))
+	assert.EqualError(err, "the document is not OpenAPI 3.1.0")
+}
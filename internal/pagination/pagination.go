@@ -0,0 +1,190 @@
+// Package pagination pages through the listings of the API with cursors.
+// A cursor is the ID of the last document of a page, and the listing goes on
+// after the snapshot of that document, so the pages stay stable while
+// documents are added.
+package pagination
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 500
+)
+
+// Page is how a listing is paged and sorted, as asked by the query
+// parameters limit, cursor, sort and order.
+type Page struct {
+	Limit int
+	// Name of the sort, e.g. joined_at
+	Sort string
+	Desc bool
+
+	// The field the sort orders by
+	field string
+	after string
+}
+
+// The cursor only holds what is needed to go on; it is opaque to the clients
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	After string `json:"a"`
+}
+
+// Parse reads the page a request asks for. sorts maps the names of the sorts
+// to the fields they order by; defaultSort, in ascending order, is the
+// default.
+func Parse(c *fiber.Ctx, sorts map[string]string, defaultSort string) (*Page, error) {
+	page := &Page{
+		Limit: DefaultLimit,
+		Sort:  defaultSort,
+	}
+
+	if value := c.Query("limit"); len(value) > 0 {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return nil, apierror.Invalid("limit", fmt.Sprintf("limit must be between 1 and %d", MaxLimit))
+		}
+		page.Limit = limit
+	}
+
+	if value := c.Query("sort"); len(value) > 0 {
+		if _, ok := sorts[value]; !ok {
+			return nil, apierror.Invalid("sort", fmt.Sprintf("sort must be one of %s", strings.Join(names(sorts), ", ")))
+		}
+		page.Sort = value
+	}
+
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return nil, apierror.Invalid("order", "order must be asc or desc")
+	}
+
+	if value := c.Query("cursor"); len(value) > 0 {
+		cur, err := decode(value)
+		if err != nil {
+			return nil, apierror.Invalid("cursor", "the cursor is not valid")
+		}
+		if cur.Sort != page.Sort || cur.Desc != page.Desc {
+			return nil, apierror.Invalid("cursor", "the cursor belongs to another sort or order")
+		}
+		page.after = cur.After
+	}
+
+	page.field = sorts[page.Sort]
+
+	return page, nil
+}
+
+func names(sorts map[string]string) []string {
+	list := make([]string, 0, len(sorts))
+	for name := range sorts {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func decode(value string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	cur := new(cursor)
+	if err := json.Unmarshal(data, cur); err != nil {
+		return nil, err
+	}
+	if len(cur.After) == 0 || strings.Contains(cur.After, "/") {
+		return nil, fmt.Errorf("the cursor has no document")
+	}
+	return cur, nil
+}
+
+// Cursor is the cursor of the page that follows a document.
+func (p *Page) Cursor(after string) string {
+	data, _ := json.Marshal(cursor{Sort: p.Sort, Desc: p.Desc, After: after})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// List reads a page of the documents of a collection that match query (the
+// collection itself, or a filter of it): the documents for which keep is
+// true too, in the order of the page, after its cursor. It returns the cursor
+// of the next page, or an empty one on the last page. keep may be nil. No
+// more than a page of documents is read, so when keep skips some of them the
+// page is short, or even empty, and the next one goes on after the last
+// document read.
+func (p *Page) List(ctx context.Context, col *firestore.CollectionRef, query firestore.Query, keep func(*firestore.DocumentSnapshot) bool) ([]*firestore.DocumentSnapshot, string, error) {
+	direction := firestore.Asc
+	if p.Desc {
+		direction = firestore.Desc
+	}
+	query = query.OrderBy(p.field, direction)
+
+	if len(p.after) > 0 {
+		after, err := col.Doc(p.after).Get(ctx)
+		if status.Code(err) == codes.NotFound {
+			return nil, "", apierror.Invalid("cursor", "the document of the cursor no longer exists")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.StartAfter(after)
+	}
+
+	// One more tells whether there is a next page
+	it := query.Limit(p.Limit + 1).Documents(ctx)
+	defer it.Stop()
+
+	snaps := make([]*firestore.DocumentSnapshot, 0)
+	read := 0
+	var last *firestore.DocumentSnapshot
+	for {
+		snap, err := it.Next()
+		if err == iterator.Done {
+			return snaps, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if read == p.Limit {
+			return snaps, p.Cursor(last.Ref.ID), nil
+		}
+		read++
+		last = snap
+		if keep == nil || keep(snap) {
+			snaps = append(snaps, snap)
+		}
+	}
+}
+
+// Link links the response to the next page, unless it is the last one.
+func Link(c *fiber.Ctx, next string) {
+	if len(next) == 0 {
+		return
+	}
+
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+	c.Context().QueryArgs().CopyTo(args)
+	args.Set("cursor", next)
+
+	c.Append(fiber.HeaderLink, fmt.Sprintf(`<%s?%s>; rel="next"`, c.Path(), args.String()))
+}
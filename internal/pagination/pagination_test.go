@@ -0,0 +1,109 @@
+package pagination
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+var sorts = map[string]string{
+	"joined_at": "timestamp",
+	"name":      "name",
+}
+
+// parse answers the page a request asks for, or the error
+func parse(assert *Assert.Assertions, query string) (*Page, *models.Error) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: utils.ErrorHandler,
+	})
+
+	var page *Page
+	app.Get("/players", func(c *fiber.Ctx) error {
+		var err error
+		page, err = Parse(c, sorts, "joined_at")
+		return err
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/players"+query, nil))
+	assert.NoError(err)
+	if res.StatusCode == 200 {
+		return page, nil
+	}
+
+	body, _ := ioutil.ReadAll(res.Body)
+	result := new(models.Error)
+	assert.NoError(json.Unmarshal(body, result))
+	return nil, result
+}
+
+func TestParseDefaults(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	page, _ := parse(assert, "")
+	assert.Equal(&Page{Limit: DefaultLimit, Sort: "joined_at", field: "timestamp"}, page)
+}
+
+func TestParse(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	cursor := (&Page{Sort: "name", Desc: true}).Cursor("player-7")
+
+	page, _ := parse(assert, "?limit=20&sort=name&order=desc&cursor="+cursor)
+	assert.Equal(&Page{Limit: 20, Sort: "name", Desc: true, field: "name", after: "player-7"}, page)
+}
+
+func TestParseInvalid(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	otherSort := (&Page{Sort: "joined_at"}).Cursor("player-7")
+
+	for query, expected := range map[string]models.FieldError{
+		"?limit=0":                       {Field: "limit", Message: "limit must be between 1 and 500"},
+		"?limit=501":                     {Field: "limit", Message: "limit must be between 1 and 500"},
+		"?sort=score":                    {Field: "sort", Message: "sort must be one of joined_at, name"},
+		"?order=up":                      {Field: "order", Message: "order must be asc or desc"},
+		"?cursor=nope":                   {Field: "cursor", Message: "the cursor is not valid"},
+		"?sort=name&cursor=" + otherSort: {Field: "cursor", Message: "the cursor belongs to another sort or order"},
+	} {
+		_, err := parse(assert, query)
+		if assert.NotNil(err, query) {
+			assert.Equal("validation_failed", err.ErrorCode)
+			assert.Equal([]models.FieldError{expected}, err.Fields, query)
+		}
+	}
+}
+
+func TestCursorOfAPath(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	_, err := decode((&Page{Sort: "name"}).Cursor("../rooms/room-1"))
+	assert.Error(err)
+}
+
+func TestLink(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	app := fiber.New()
+	app.Get("/v1/players", func(c *fiber.Ctx) error {
+		Link(c, c.Query("next"))
+		return nil
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/v1/players?limit=2&cursor=abc&next=def", nil))
+	assert.NoError(err)
+	assert.Equal(`</v1/players?limit=2&cursor=def&next=def>; rel="next"`, res.Header.Get("Link"))
+
+	res, err = app.Test(httptest.NewRequest("GET", "/v1/players?limit=2", nil))
+	assert.NoError(err)
+	assert.Empty(res.Header.Get("Link"), "the last page")
+}
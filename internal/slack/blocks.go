@@ -0,0 +1,147 @@
+package slack
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"strconv"
+	"strings"
+)
+
+const (
+	ActionVotePrefix = "vote_"
+	ActionReveal     = "reveal"
+)
+
+// Message is a Slack message written with Block Kit, used both as the
+// response of a slash command and as the body posted to a response_url.
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"`
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+type Text struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+type Element struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	ActionId string `json:"action_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+type Block struct {
+	Type    string `json:"type"`
+	BlockId string `json:"block_id,omitempty"`
+	Text    *Text  `json:"text,omitempty"`
+	Fields  []Text `json:"fields,omitempty"`
+	// Buttons in actions blocks, texts in context blocks
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+var mrkdwnReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape escapes the characters Slack reserves for links and mentions.
+func Escape(text string) string {
+	return mrkdwnReplacer.Replace(text)
+}
+
+func markdown(text string) *Text {
+	return &Text{Type: "mrkdwn", Text: text}
+}
+
+func plain(text string) *Text {
+	return &Text{Type: "plain_text", Text: text, Emoji: true}
+}
+
+// Ephemeral is a message only the user who ran the command sees.
+func Ephemeral(text string) Message {
+	return Message{ResponseType: "ephemeral", Text: text}
+}
+
+// VotingMessage is posted to the channel while a round is open: one button per
+// card and one to reveal the votes.
+func VotingMessage(story string, value ActionValue, deck []string, voters []string) Message {
+	cards := make([]interface{}, len(deck))
+	for i, card := range deck {
+		cards[i] = Element{
+			Type:     "button",
+			Text:     plain(card),
+			ActionId: ActionVotePrefix + strconv.Itoa(i),
+			Value:    ActionValue{PinCode: value.PinCode, Round: value.Round, Card: card}.String(),
+		}
+	}
+
+	status := "Nobody voted yet"
+	if len(voters) > 0 {
+		escaped := make([]string, len(voters))
+		for i, voter := range voters {
+			escaped[i] = Escape(voter)
+		}
+		status = fmt.Sprintf("%d voted: %s", len(voters), strings.Join(escaped, ", "))
+	}
+
+	return Message{
+		ResponseType:    "in_channel",
+		ReplaceOriginal: true,
+		Text:            fmt.Sprintf("Planning poker: %s", story),
+		Blocks: []Block{
+			{Type: "section", Text: markdown(fmt.Sprintf(":black_joker: *%s*\nRoom `%s` · round %d", Escape(story), value.PinCode, value.Round))},
+			{Type: "actions", BlockId: "cards", Elements: cards},
+			{Type: "actions", BlockId: "controls", Elements: []interface{}{Element{
+				Type:     "button",
+				Text:     plain("Reveal"),
+				ActionId: ActionReveal,
+				Value:    ActionValue{PinCode: value.PinCode, Round: value.Round}.String(),
+				Style:    "primary",
+			}}},
+			{Type: "context", Elements: []interface{}{markdown(status)}},
+		},
+	}
+}
+
+// ResultsMessage replaces the voting message once the round is revealed.
+func ResultsMessage(pinCode string, summary rounds.Summary) Message {
+	title := summary.StoryTitle
+	if len(title) == 0 {
+		title = fmt.Sprintf("Round %d", summary.Number)
+	}
+
+	fields := make([]Text, 0, len(summary.Votes))
+	for _, vote := range summary.Votes {
+		fields = append(fields, Text{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", Escape(vote.PlayerName), Escape(vote.Value))})
+	}
+
+	result := "No votes"
+	if summary.Average != nil {
+		result = fmt.Sprintf("Average: *%s*", strconv.FormatFloat(*summary.Average, 'f', -1, 64))
+	}
+	if summary.Consensus {
+		result += " · :tada: consensus"
+	}
+
+	blocks := []Block{
+		{Type: "section", Text: markdown(fmt.Sprintf(":black_joker: *%s*\nRoom `%s` · round %d revealed", Escape(title), pinCode, summary.Number))},
+	}
+	// Slack accepts at most 10 fields per section
+	for start := 0; start < len(fields); start += 10 {
+		end := start + 10
+		if end > len(fields) {
+			end = len(fields)
+		}
+		blocks = append(blocks, Block{Type: "section", Fields: fields[start:end]})
+	}
+	blocks = append(blocks, Block{Type: "context", Elements: []interface{}{markdown(result)}})
+
+	return Message{
+		ResponseType:    "in_channel",
+		ReplaceOriginal: true,
+		Text:            fmt.Sprintf("Results for %s", title),
+		Blocks:          blocks,
+	}
+}
@@ -0,0 +1,171 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Slack-Signature"
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+
+	// Requests older than this are refused to prevent replay attacks
+	maxRequestAge = 5 * time.Minute
+)
+
+var ErrInvalidSignature = errors.New("invalid slack signature")
+
+// VerifySignature checks the signature Slack sends with every request, as
+// described in https://api.slack.com/authentication/verifying-requests-from-slack
+func VerifySignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	if len(secret) == 0 || len(timestamp) == 0 || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Sign computes the value of the X-Slack-Signature header for a request.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Command is the form Slack posts when someone runs a slash command.
+type Command struct {
+	TeamId      string
+	ChannelId   string
+	UserId      string
+	UserName    string
+	Command     string
+	Text        string
+	ResponseUrl string
+}
+
+func ParseCommand(body []byte) (*Command, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, errors.New("invalid slash command payload")
+	}
+
+	cmd := &Command{
+		TeamId:      form.Get("team_id"),
+		ChannelId:   form.Get("channel_id"),
+		UserId:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		Command:     form.Get("command"),
+		Text:        strings.TrimSpace(form.Get("text")),
+		ResponseUrl: form.Get("response_url"),
+	}
+	if len(cmd.Command) == 0 || len(cmd.UserId) == 0 {
+		return nil, errors.New("invalid slash command payload")
+	}
+
+	return cmd, nil
+}
+
+type Action struct {
+	ActionId string `json:"action_id"`
+	Value    string `json:"value"`
+}
+
+// Interaction is the payload Slack posts when someone clicks a button of a
+// message.
+type Interaction struct {
+	Type string `json:"type"`
+	Team struct {
+		Id string `json:"id"`
+	} `json:"team"`
+	User struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Actions     []Action `json:"actions"`
+	ResponseUrl string   `json:"response_url"`
+}
+
+// DisplayName returns the best name available for the user.
+func (i *Interaction) DisplayName() string {
+	if len(i.User.Username) > 0 {
+		return i.User.Username
+	}
+	if len(i.User.Name) > 0 {
+		return i.User.Name
+	}
+	return i.User.Id
+}
+
+// ParseInteraction reads the "payload" field of the form posted by Slack.
+func ParseInteraction(body []byte) (*Interaction, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, errors.New("invalid interaction payload")
+	}
+
+	interaction := new(Interaction)
+	if err := json.Unmarshal([]byte(form.Get("payload")), interaction); err != nil {
+		return nil, errors.New("invalid interaction payload")
+	}
+	if len(interaction.User.Id) == 0 {
+		return nil, errors.New("invalid interaction payload")
+	}
+
+	return interaction, nil
+}
+
+// ActionValue is what the buttons of a voting message carry, so the callback
+// knows which room and round they belong to.
+type ActionValue struct {
+	PinCode string
+	Round   int
+	Card    string
+}
+
+func (v ActionValue) String() string {
+	parts := []string{v.PinCode, strconv.Itoa(v.Round)}
+	if len(v.Card) > 0 {
+		parts = append(parts, v.Card)
+	}
+	return strings.Join(parts, "|")
+}
+
+func ParseActionValue(value string) (ActionValue, error) {
+	parts := strings.SplitN(value, "|", 3)
+	if len(parts) < 2 {
+		return ActionValue{}, errors.New("invalid action value")
+	}
+
+	round, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ActionValue{}, errors.New("invalid action value")
+	}
+
+	v := ActionValue{PinCode: parts[0], Round: round}
+	if len(parts) == 3 {
+		v.Card = parts[2]
+	}
+	return v, nil
+}
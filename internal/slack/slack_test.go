@@ -0,0 +1,161 @@
+package slack
+
+import (
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte("command=%2Fpoker&text=Login")
+
+	assert.NoError(VerifySignature("secret", timestamp, Sign("secret", timestamp, body), body, now))
+	assert.Equal(ErrInvalidSignature, VerifySignature("secret", timestamp, Sign("other", timestamp, body), body, now))
+	assert.Equal(ErrInvalidSignature, VerifySignature("secret", timestamp, Sign("secret", timestamp, body), []byte("tampered"), now))
+	assert.Equal(ErrInvalidSignature, VerifySignature("secret", timestamp, Sign("secret", timestamp, body), body, now.Add(10*time.Minute)))
+	assert.Equal(ErrInvalidSignature, VerifySignature("", timestamp, Sign("", timestamp, body), body, now))
+	assert.Equal(ErrInvalidSignature, VerifySignature("secret", "yesterday", Sign("secret", "yesterday", body), body, now))
+}
+
+func TestSignMatchesSlackExample(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	// Example from https://api.slack.com/authentication/verifying-requests-from-slack
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c")
+
+	assert.Equal("v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+		Sign("8f742231b10e8888abcd99yyyzzz85a5", "1531420618", body))
+}
+
+func TestParseCommand(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	form := url.Values{
+		"team_id":      {"T1"},
+		"channel_id":   {"C1"},
+		"user_id":      {"U1"},
+		"user_name":    {"ana"},
+		"command":      {"/poker"},
+		"text":         {"  Login page  "},
+		"response_url": {"https://hooks.slack.com/commands/1"},
+	}
+
+	cmd, err := ParseCommand([]byte(form.Encode()))
+	assert.NoError(err)
+	assert.Equal(&Command{
+		TeamId:      "T1",
+		ChannelId:   "C1",
+		UserId:      "U1",
+		UserName:    "ana",
+		Command:     "/poker",
+		Text:        "Login page",
+		ResponseUrl: "https://hooks.slack.com/commands/1",
+	}, cmd)
+
+	_, err = ParseCommand([]byte("text=Login"))
+	assert.Error(err)
+}
+
+func TestParseInteraction(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	payload := `{"type":"block_actions","team":{"id":"T1"},"user":{"id":"U1","username":"ana"},` +
+		`"actions":[{"action_id":"vote_3","value":"123456|1|3"}],"response_url":"https://hooks.slack.com/actions/1"}`
+	form := url.Values{"payload": {payload}}
+
+	interaction, err := ParseInteraction([]byte(form.Encode()))
+	assert.NoError(err)
+	assert.Equal("block_actions", interaction.Type)
+	assert.Equal("T1", interaction.Team.Id)
+	assert.Equal("ana", interaction.DisplayName())
+	assert.Equal([]Action{{ActionId: "vote_3", Value: "123456|1|3"}}, interaction.Actions)
+	assert.Equal("https://hooks.slack.com/actions/1", interaction.ResponseUrl)
+
+	_, err = ParseInteraction([]byte("payload=not-json"))
+	assert.Error(err)
+}
+
+func TestActionValue(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	value := ActionValue{PinCode: "123456", Round: 2, Card: "13"}
+	parsed, err := ParseActionValue(value.String())
+	assert.NoError(err)
+	assert.Equal(value, parsed)
+
+	parsed, err = ParseActionValue("123456|2")
+	assert.NoError(err)
+	assert.Equal(ActionValue{PinCode: "123456", Round: 2}, parsed)
+
+	_, err = ParseActionValue("123456")
+	assert.Error(err)
+	_, err = ParseActionValue("123456|two")
+	assert.Error(err)
+}
+
+func TestVotingMessage(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	message := VotingMessage("Login <page>", ActionValue{PinCode: "123456", Round: 1}, []string{"1", "2"}, []string{"Ana"})
+
+	data, err := json.Marshal(message)
+	assert.NoError(err)
+
+	decoded := make(map[string]interface{})
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.Equal("in_channel", decoded["response_type"])
+
+	blocks := decoded["blocks"].([]interface{})
+	assert.Len(blocks, 4)
+	assert.Contains(blocks[0].(map[string]interface{})["text"].(map[string]interface{})["text"], "Login &lt;page&gt;")
+
+	cards := blocks[1].(map[string]interface{})["elements"].([]interface{})
+	assert.Len(cards, 2)
+	assert.Equal("vote_1", cards[1].(map[string]interface{})["action_id"])
+	assert.Equal("123456|1|2", cards[1].(map[string]interface{})["value"])
+
+	reveal := blocks[2].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(ActionReveal, reveal["action_id"])
+	assert.Equal("123456|1", reveal["value"])
+
+	status := blocks[3].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})
+	assert.Equal("mrkdwn", status["type"])
+	assert.Equal("1 voted: Ana", status["text"])
+}
+
+func TestResultsMessage(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	average := 4.0
+	message := ResultsMessage("123456", rounds.Summary{
+		Number:     1,
+		StoryTitle: "Login",
+		Votes: []rounds.Vote{
+			{PlayerName: "Ana", Value: "3"},
+			{PlayerName: "Bob", Value: "5"},
+		},
+		Distribution: map[string]int{"3": 1, "5": 1},
+		Average:      &average,
+	})
+
+	assert.Equal("Results for Login", message.Text)
+	assert.True(message.ReplaceOriginal)
+	assert.Len(message.Blocks, 3)
+	assert.Equal([]Text{{Type: "mrkdwn", Text: "*Ana*\n3"}, {Type: "mrkdwn", Text: "*Bob*\n5"}}, message.Blocks[1].Fields)
+	assert.Equal(markdown("Average: *4*"), message.Blocks[2].Elements[0])
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	internal "github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier lets the propagator read the headers of a request.
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (h headerCarrier) Get(key string) string {
+	return string(h.header.Peek(key))
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// NewMiddleware is a Fiber middleware that starts a server span for every
+// request, continuing the trace of the traceparent header. The context of
+// the request, with the span, is available through utils.Context.
+func NewMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := Propagator().Extract(context.Background(), headerCarrier{header: &c.Request().Header})
+
+		ctx, span := Tracer().Start(ctx, c.Method(), trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		c.Locals(internal.LocalContext, ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+
+		// The span outlives the buffers of the request, where the method and
+		// the URL are.
+		method, route := utils.CopyString(c.Method()), c.Route().Path
+		span.SetName(method + " " + route)
+		span.SetAttributes(
+			semconv.HTTPMethodKey.String(method),
+			semconv.HTTPRouteKey.String(route),
+			semconv.HTTPTargetKey.String(utils.CopyString(c.OriginalURL())),
+			semconv.HTTPStatusCodeKey.Int(status),
+		)
+		if requestId := internal.RequestId(c); len(requestId) > 0 {
+			span.SetAttributes(attribute.String("http.request_id", requestId))
+		}
+		if status >= 500 {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+			}
+		}
+
+		return err
+	}
+}
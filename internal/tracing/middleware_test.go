@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"testing"
+)
+
+// recordSpans makes the global provider record the spans, until the test
+// ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	recorder := recordSpans(t)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	app.Use(NewMiddleware())
+	app.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+		_, span := Tracer().Start(utils.Context(c), "firestore.RunQuery", trace.WithSpanKind(trace.SpanKindClient))
+		span.End()
+		return c.SendStatus(500)
+	})
+
+	req, _ := http.NewRequest("GET", "/rooms/123456/players?x=1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res, err := app.Test(req)
+	assert.NoError(err)
+	assert.Equal(500, res.StatusCode)
+
+	spans := recorder.Ended()
+	if !assert.Len(spans, 2) {
+		return
+	}
+	child, server := spans[0], spans[1]
+
+	assert.Equal("GET /rooms/:pincode/players", server.Name())
+	assert.Equal(trace.SpanKindServer, server.SpanKind())
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal("00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.True(server.Parent().IsRemote())
+	assert.Contains(server.Attributes(), attribute.String("http.route", "/rooms/:pincode/players"))
+	assert.Contains(server.Attributes(), attribute.String("http.target", "/rooms/123456/players?x=1"))
+	assert.Contains(server.Attributes(), attribute.Int("http.status_code", 500))
+	assert.Equal(codes.Error, server.Status().Code)
+
+	assert.Equal(server.SpanContext().TraceID(), child.SpanContext().TraceID())
+	assert.Equal(server.SpanContext().SpanID(), child.Parent().SpanID())
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Name of the instrumentation library that starts the spans of the API
+const Name = "github.com/thiagopereiramartinez/scrumpoker-run.api"
+
+// Tracer starts the spans of the API with the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Propagator carries the trace context between services, in the traceparent
+// header defined by W3C Trace Context (https://www.w3.org/TR/trace-context/).
+func Propagator() propagation.TextMapPropagator {
+	return propagation.TraceContext{}
+}
+
+// discard drops the spans of a provider without exporter, which can't shut
+// down without any processor.
+type discard struct{}
+
+func (discard) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return nil
+}
+
+func (discard) Shutdown(context.Context) error {
+	return nil
+}
+
+// NewProvider returns a provider that exports the spans in batches. Without
+// an exporter the new traces are not sampled, though their spans still get
+// IDs to correlate the logs. Either way the sampling decision of the caller
+// is kept.
+func NewProvider(exporter sdktrace.SpanExporter, serviceName string) *sdktrace.TracerProvider {
+	root, processor := sdktrace.NeverSample(), sdktrace.NewSimpleSpanProcessor(discard{})
+	if exporter != nil {
+		root, processor = sdktrace.AlwaysSample(), sdktrace.NewBatchSpanProcessor(exporter)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(root)),
+		sdktrace.WithSpanProcessor(processor),
+	)
+}
+
+// NewStdoutExporter writes the spans as JSON, for local runs.
+func NewStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w))
+}
+
+// NewOTLPExporter sends the spans to the collector at endpoint, e.g.
+// http://localhost:4318, with OTLP over HTTP.
+func NewOTLPExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(strings.TrimRight(u.Path, "/") + "/v1/traces"),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(context.Background(), opts...)
+}
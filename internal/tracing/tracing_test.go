@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	Assert "github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// keptSpans keeps the exported spans after the shutdown of the provider,
+// which is what exports the last ones.
+type keptSpans struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keptSpans) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestNewProvider(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	exporter := keptSpans{tracetest.NewInMemoryExporter()}
+	provider := NewProvider(exporter, "scrumpoker-api")
+
+	ctx, parent := provider.Tracer(Name).Start(context.Background(), "parent")
+	_, child := provider.Tracer(Name).Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	assert.True(parent.SpanContext().IsSampled())
+	assert.Equal(parent.SpanContext().TraceID(), child.SpanContext().TraceID())
+
+	assert.NoError(provider.Shutdown(context.Background()))
+
+	spans := exporter.GetSpans()
+	if assert.Len(spans, 2) {
+		assert.Equal("child", spans[0].Name)
+		assert.Equal(parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+		name, _ := spans[1].Resource.Set().Value(semconv.ServiceNameKey)
+		assert.Equal("scrumpoker-api", name.AsString())
+	}
+}
+
+func TestNewProviderWithoutExporter(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	provider := NewProvider(nil, "scrumpoker-api")
+
+	_, span := provider.Tracer(Name).Start(context.Background(), "request")
+	span.End()
+
+	assert.True(span.SpanContext().IsValid(), "spans still get IDs to correlate the logs")
+	assert.False(span.SpanContext().IsSampled())
+
+	carrier := propagation.HeaderCarrier{}
+	carrier.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Propagator().Extract(context.Background(), carrier)
+	_, span = provider.Tracer(Name).Start(ctx, "request")
+	span.End()
+
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.True(span.SpanContext().IsSampled(), "the sampling decision of the caller is kept")
+	assert.NoError(provider.Shutdown(context.Background()))
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	paths := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		paths <- r.URL.Path
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(collector.URL + "/otel/")
+	if !assert.NoError(err) {
+		return
+	}
+	provider := NewProvider(exporter, "scrumpoker-api")
+
+	_, span := provider.Tracer(Name).Start(context.Background(), "request")
+	span.End()
+	assert.NoError(provider.Shutdown(context.Background()))
+
+	assert.Equal("/otel/v1/traces", <-paths)
+
+	_, err = NewOTLPExporter("localhost:4318")
+	assert.Error(err)
+}
@@ -2,15 +2,44 @@ package utils
 
 import (
 	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"net/http"
+	"strings"
 )
 
+const MIMEProblemJSON = "application/problem+json"
+
+// Message of the internal errors: their own text, e.g. from Firestore, is
+// only logged
+const internalMessage = "internal server error"
+
 type SenderContext interface {
+	Status(status int) *fiber.Ctx
 	JSON(interface{}) error
-	SendStatus(statusCode int) error
 }
 
-func SendError(c SenderContext, statusCode int, err error) error {
+// HeaderContext is a SenderContext that can read and write headers, so the
+// error can be sent as problem details to the clients that ask for them.
+type HeaderContext interface {
+	Get(key string, defaultValue ...string) string
+	Set(key string, val string)
+}
+
+// ErrorHandler answers the errors returned by the handlers, as the
+// ErrorHandler of the Fiber app.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		err = apierror.FromStatus(fe.Code, fe.Message)
+	}
+
+	return SendError(c, err)
+}
+
+// SendError answers with err, with the status of its code (see apierror.From).
+func SendError(c SenderContext, err error) error {
 	if err == nil {
 		return errors.New("error property cannot be nil")
 	}
@@ -18,15 +47,35 @@ func SendError(c SenderContext, statusCode int, err error) error {
 		return errors.New("sender property cannot be nil")
 	}
 
-	if err := c.JSON(models.Error{
-		Code:    statusCode,
-		Message: err.Error(),
-	}); err != nil {
-		return err
+	e := apierror.From(err)
+	statusCode := e.Status()
+
+	body := models.Error{
+		Code:      statusCode,
+		ErrorCode: string(e.Code),
+		Message:   e.Message,
+		Fields:    e.Fields,
+	}
+	if e.Code == apierror.Internal {
+		body.Message = internalMessage
 	}
-	if err := c.SendStatus(statusCode); err != nil {
-		return err
+	if lc, ok := c.(LocalsContext); ok {
+		body.RequestId = RequestId(lc)
+	}
+
+	c.Status(statusCode)
+
+	if hc, ok := c.(HeaderContext); ok && strings.Contains(hc.Get("Accept"), MIMEProblemJSON) {
+		title := http.StatusText(statusCode)
+		if len(title) == 0 {
+			title = string(e.Code)
+		}
+		if err := c.JSON(models.NewProblem(body, title)); err != nil {
+			return err
+		}
+		hc.Set("Content-Type", MIMEProblemJSON)
+		return nil
 	}
 
-	return nil
+	return c.JSON(body)
 }
@@ -1,10 +1,18 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"github.com/gofiber/fiber/v2"
 	Assert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
 	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -17,9 +25,34 @@ func (m *MockCtx) JSON(i interface{}) error {
 	return args.Error(0)
 }
 
-func (m *MockCtx) SendStatus(statusCode int) error {
-	args := m.Called(statusCode)
-	return args.Error(0)
+func (m *MockCtx) Status(status int) *fiber.Ctx {
+	m.Called(status)
+	return nil
+}
+
+type MockLocalsCtx struct {
+	MockCtx
+	locals map[string]interface{}
+}
+
+func (m *MockLocalsCtx) Locals(key string, value ...interface{}) interface{} {
+	if len(value) > 0 {
+		m.locals[key] = value[0]
+	}
+	return m.locals[key]
+}
+
+type MockHeaderCtx struct {
+	MockCtx
+	headers map[string]string
+}
+
+func (m *MockHeaderCtx) Get(key string, defaultValue ...string) string {
+	return m.headers[key]
+}
+
+func (m *MockHeaderCtx) Set(key string, val string) {
+	m.headers[key] = val
 }
 
 func TestSendErrorValid(t *testing.T) {
@@ -28,13 +61,138 @@ func TestSendErrorValid(t *testing.T) {
 
 	m := new(MockCtx)
 	m.On("JSON", models.Error{
-		Code:    500,
-		Message: "test error",
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
+	}).Return(nil)
+	m.On("Status", 404)
+
+	err := SendError(m, apierror.New(apierror.RoomNotFound, "room not found"))
+	assert.NoError(err)
+
+	m.AssertExpectations(t)
+
+}
+
+func TestSendErrorUntyped(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	m := new(MockCtx)
+	m.On("JSON", models.Error{
+		Code:      500,
+		ErrorCode: "internal",
+		Message:   "internal server error",
+	}).Return(nil)
+	m.On("Status", 500)
+
+	err := SendError(m, errors.New("test error"))
+	assert.NoError(err)
+
+	m.AssertExpectations(t)
+
+}
+
+func TestSendErrorValidationFailed(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	m := new(MockCtx)
+	m.On("JSON", models.Error{
+		Code:      400,
+		ErrorCode: "validation_failed",
+		Message:   "the name of the room is required",
+		Fields: []models.FieldError{
+			{Field: "name", Message: "the name of the room is required"},
+		},
+	}).Return(nil)
+	m.On("Status", 400)
+
+	err := SendError(m, apierror.Invalid("name", "the name of the room is required"))
+	assert.NoError(err)
+
+	m.AssertExpectations(t)
+
+}
+
+func TestSendErrorWithRequestId(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	m := &MockLocalsCtx{locals: map[string]interface{}{LocalRequestId: "abc-123"}}
+	m.On("JSON", models.Error{
+		Code:      404,
+		ErrorCode: "room_not_found",
+		Message:   "room not found",
+		RequestId: "abc-123",
+	}).Return(nil)
+	m.On("Status", 404)
+
+	err := SendError(m, apierror.New(apierror.RoomNotFound, "room not found"))
+	assert.NoError(err)
+
+	m.AssertExpectations(t)
+
+}
+
+func TestSendErrorAsProblem(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	m := &MockHeaderCtx{headers: map[string]string{"Accept": "application/problem+json, application/json"}}
+	m.On("JSON", models.Problem{
+		Type:   "about:blank",
+		Title:  "Unauthorized",
+		Status: 401,
+		Detail: "only the facilitator of the room can manage webhooks",
+		Code:   "unauthorized",
 	}).Return(nil)
-	m.On("SendStatus", 500).Return(nil)
+	m.On("Status", 401)
 
-	err := SendError(m, 500, errors.New("test error"))
+	err := SendError(m, apierror.New(apierror.Unauthorized, "only the facilitator of the room can manage webhooks"))
 	assert.NoError(err)
+	assert.Equal("application/problem+json", m.headers["Content-Type"])
+
+	m.AssertExpectations(t)
+
+}
+
+func TestSendErrorDeadlineExceeded(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for _, err := range []error{
+		context.DeadlineExceeded,
+		status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+	} {
+		m := new(MockCtx)
+		m.On("JSON", models.Error{
+			Code:      504,
+			ErrorCode: "timeout",
+			Message:   "the storage took too long to answer",
+		}).Return(nil)
+		m.On("Status", 504)
+
+		assert.NoError(SendError(m, err))
+
+		m.AssertExpectations(t)
+	}
+
+}
+
+func TestSendErrorCanceled(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	m := new(MockCtx)
+	m.On("JSON", models.Error{
+		Code:      499,
+		ErrorCode: "canceled",
+		Message:   "the request was canceled",
+	}).Return(nil)
+	m.On("Status", 499)
+
+	assert.NoError(SendError(m, status.Error(codes.Canceled, "context canceled")))
 
 	m.AssertExpectations(t)
 
@@ -46,7 +204,7 @@ func TestSendErrorNil(t *testing.T) {
 
 	m := new(MockCtx)
 
-	err := SendError(m, 500, nil)
+	err := SendError(m, nil)
 	assert.Error(err)
 	assert.Equal("error property cannot be nil", err.Error())
 
@@ -56,7 +214,7 @@ func TestSendSenderNil(t *testing.T) {
 
 	assert := Assert.New(t)
 
-	err := SendError(nil, 500, errors.New("new error"))
+	err := SendError(nil, errors.New("new error"))
 	assert.Error(err)
 	assert.Equal("sender property cannot be nil", err.Error())
 
@@ -68,11 +226,13 @@ func TestSendErrorFailInJSON(t *testing.T) {
 
 	m := new(MockCtx)
 	m.On("JSON", models.Error{
-		Code:    500,
-		Message: "test error",
+		Code:      500,
+		ErrorCode: "internal",
+		Message:   "internal server error",
 	}).Return(errors.New("error to generate JSON"))
+	m.On("Status", 500)
 
-	err := SendError(m, 500, errors.New("test error"))
+	err := SendError(m, errors.New("test error"))
 	assert.Error(err)
 	assert.Equal("error to generate JSON", err.Error())
 
@@ -80,21 +240,34 @@ func TestSendErrorFailInJSON(t *testing.T) {
 
 }
 
-func TestSendErrorFailInSendStatus(t *testing.T) {
+func TestErrorHandler(t *testing.T) {
 
 	assert := Assert.New(t)
 
-	m := new(MockCtx)
-	m.On("JSON", models.Error{
-		Code:    500,
-		Message: "test error",
-	}).Return(nil)
-	m.On("SendStatus", 500).Return(errors.New("error to send status code"))
+	app := fiber.New(fiber.Config{
+		ErrorHandler: ErrorHandler,
+	})
+	app.Get("/rooms", func(c *fiber.Ctx) error {
+		return errors.New("rpc error: code = Unavailable desc = connection refused")
+	})
 
-	err := SendError(m, 500, errors.New("test error"))
-	assert.Error(err)
-	assert.Equal("error to send status code", err.Error())
+	// Errors of Fiber get a code too
+	res, err := app.Test(httptest.NewRequest("POST", "/rooms", nil))
+	assert.NoError(err)
+	assert.Equal(405, res.StatusCode)
 
-	m.AssertExpectations(t)
+	body, _ := ioutil.ReadAll(res.Body)
+	result := new(models.Error)
+	assert.NoError(json.Unmarshal(body, result))
+	assert.Equal("method_not_allowed", result.ErrorCode)
+
+	// The text of internal errors is not sent
+	res, err = app.Test(httptest.NewRequest("GET", "/rooms", nil))
+	assert.NoError(err)
+	assert.Equal(500, res.StatusCode)
 
+	body, _ = ioutil.ReadAll(res.Body)
+	assert.NoError(json.Unmarshal(body, result))
+	assert.Equal("internal", result.ErrorCode)
+	assert.Equal("internal server error", result.Message)
 }
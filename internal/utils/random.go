@@ -0,0 +1,19 @@
+package utils
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+)
+
+// NewRand returns a source of random numbers seeded from crypto/rand, for
+// what the players must not be able to guess, like the order of the cards
+// of the anonymous rounds. The global source of math/rand always starts
+// from the same seed.
+func NewRand() (*rand.Rand, error) {
+	var seed int64
+	if err := binary.Read(crand.Reader, binary.BigEndian, &seed); err != nil {
+		return nil, err
+	}
+	return rand.New(rand.NewSource(seed)), nil
+}
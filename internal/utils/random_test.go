@@ -0,0 +1,18 @@
+package utils
+
+import (
+	Assert "github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewRand(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	a, err := NewRand()
+	assert.NoError(err)
+	b, err := NewRand()
+	assert.NoError(err)
+
+	assert.NotEqual(a.Int63(), b.Int63(), "every source gets its own seed")
+}
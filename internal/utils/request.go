@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// Keys of the values kept in the locals of a request
+const (
+	LocalRequestId = "requestid"
+	LocalRoomId    = "room_id"
+	LocalPlayerId  = "player_id"
+	LocalContext   = "context"
+)
+
+// LocalsContext is a request that carries values, like *fiber.Ctx.
+type LocalsContext interface {
+	Locals(key string, value ...interface{}) interface{}
+}
+
+// RequestId is the ID of the request, as set by the request ID middleware.
+func RequestId(c LocalsContext) string {
+	id, _ := c.Locals(LocalRequestId).(string)
+	return id
+}
+
+// SetRoom tells the request log which room the request is about.
+func SetRoom(c LocalsContext, roomId string) {
+	c.Locals(LocalRoomId, roomId)
+}
+
+// SetPlayer tells the request log which player made the request.
+func SetPlayer(c LocalsContext, playerId string) {
+	c.Locals(LocalPlayerId, playerId)
+}
+
+// Context is the context of the request, carrying its trace. Requests that
+// didn't go through the tracing middleware get an empty context.
+func Context(c LocalsContext) context.Context {
+	if ctx, ok := c.Locals(LocalContext).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// Detach keeps the values of ctx, like its span, without its deadline or
+// cancellation, for work that goes on after the handler returns.
+func Detach(ctx context.Context) context.Context {
+	return detached{ctx}
+}
+
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	Assert "github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewToken(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	a, err := NewToken()
+	assert.NoError(err)
+	b, err := NewToken()
+	assert.NoError(err)
+
+	assert.Len(a, 32)
+	assert.NotEqual(a, b)
+}
+
+func TestCheckToken(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	token, _ := NewToken()
+	hash := HashToken(token)
+
+	assert.True(CheckToken(token, hash))
+	assert.False(CheckToken(token+"x", hash))
+	assert.False(CheckToken("", hash))
+	assert.False(CheckToken(token, ""))
+}
+
+func TestBearerToken(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	assert.Equal("abc", BearerToken("Bearer abc"))
+	assert.Equal("abc", BearerToken("bearer  abc "))
+	assert.Equal("", BearerToken("Basic abc"))
+	assert.Equal("", BearerToken(""))
+}
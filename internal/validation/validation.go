@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"golang.org/x/text/unicode/norm"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule checks a text, returning what is wrong with it, to complete the label
+// of the field (e.g. "is required"), or an empty string when it is valid.
+type Rule func(value string) string
+
+// Validator collects the errors of the fields of a request, so all of them
+// are reported at once.
+type Validator struct {
+	fields []models.FieldError
+}
+
+func New() *Validator {
+	return new(Validator)
+}
+
+// Add reports an error of a field.
+func (v *Validator) Add(field, message string) {
+	v.fields = append(v.fields, models.FieldError{Field: field, Message: message})
+}
+
+// Text cleans a text field, trimming the spaces around it and normalizing it
+// to NFC, and checks it against the rules. Only the first rule it breaks is
+// reported, e.g. "the name of the room is required".
+func (v *Validator) Text(field, label string, value *string, rules ...Rule) {
+	*value = norm.NFC.String(strings.TrimSpace(*value))
+
+	for _, rule := range rules {
+		if problem := rule(*value); len(problem) > 0 {
+			v.Add(field, label+" "+problem)
+			return
+		}
+	}
+}
+
+// Err is the validation_failed error with all the fields reported, or nil.
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return apierror.Validation(v.fields)
+}
+
+func Required(value string) string {
+	if len(value) == 0 {
+		return "is required"
+	}
+	return ""
+}
+
+// MaxLength limits the characters of the text (not its bytes).
+func MaxLength(max int) Rule {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Sprintf("must have at most %d characters", max)
+		}
+		return ""
+	}
+}
+
+// NoControl forbids the control characters, like line breaks, and the ones
+// that change the direction of the text around it.
+func NoControl(value string) string {
+	for _, r := range value {
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			return "must not contain control characters"
+		}
+	}
+	return ""
+}
+
+func OneOf(values ...string) Rule {
+	return func(value string) string {
+		for _, v := range values {
+			if value == v {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}
+}
+
+func HttpUrl(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return "must be an http(s) URL"
+	}
+	return ""
+}
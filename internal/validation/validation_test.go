@@ -0,0 +1,63 @@
+package validation
+
+import (
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"testing"
+)
+
+func TestValidatorValid(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	name := " Sprint 42 "
+
+	v := New()
+	v.Text("name", "the name", &name, Required, MaxLength(10), NoControl)
+
+	assert.NoError(v.Err())
+	assert.Equal("Sprint 42", name)
+}
+
+func TestValidatorReportsAllFields(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	name, role, link := "   ", "owner", "example.com"
+
+	v := New()
+	v.Text("name", "the name", &name, Required, MaxLength(10))
+	v.Text("role", "the role", &role, OneOf("player", "observer"))
+	v.Text("link", "the link", &link, HttpUrl)
+	err := v.Err()
+
+	e := new(apierror.Error)
+	if assert.ErrorAs(err, &e) {
+		assert.Equal(apierror.ValidationFailed, e.Code)
+		assert.Equal([]models.FieldError{
+			{Field: "name", Message: "the name is required"},
+			{Field: "role", Message: "the role must be one of player, observer"},
+			{Field: "link", Message: "the link must be an http(s) URL"},
+		}, e.Fields)
+		assert.Equal("the name is required; the role must be one of player, observer; the link must be an http(s) URL", e.Message)
+	}
+}
+
+func TestMaxLengthCountsCharacters(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	assert.Empty(MaxLength(3)("☕☕☕"))
+	assert.Equal("must have at most 3 characters", MaxLength(3)("☕☕☕☕"))
+}
+
+func TestNoControl(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	assert.Empty(NoControl("Ana Bob"))
+	assert.NotEmpty(NoControl("Ana\tBob"))
+	assert.NotEmpty(NoControl("Ana\x00"))
+	assert.NotEmpty(NoControl("Ana\u2066Bob"))
+}
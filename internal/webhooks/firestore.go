@@ -0,0 +1,48 @@
+package webhooks
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+)
+
+// FirestoreStore reads the webhooks of a room and keeps its delivery log in
+// the "webhooks" and "webhook_deliveries" collections of the room.
+type FirestoreStore struct {
+	db *firestore.Client
+}
+
+func NewFirestoreStore(db *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{db: db}
+}
+
+func (s *FirestoreStore) Webhooks(ctx context.Context, roomId string) ([]webhooks.Webhook, error) {
+	snaps, err := s.db.Collection("rooms").Doc(roomId).Collection("webhooks").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := make([]webhooks.Webhook, len(snaps))
+	for i, snap := range snaps {
+		if err := snap.DataTo(&hooks[i]); err != nil {
+			return nil, err
+		}
+		hooks[i].Id = snap.Ref.ID
+	}
+
+	return hooks, nil
+}
+
+func (s *FirestoreStore) Record(ctx context.Context, roomId string, delivery webhooks.Delivery) error {
+	_, err := s.db.Collection("rooms").Doc(roomId).Collection("webhook_deliveries").Doc(delivery.Id).Set(ctx, map[string]interface{}{
+		"webhook_id":  delivery.WebhookId,
+		"event":       delivery.Event,
+		"url":         delivery.Url,
+		"attempts":    delivery.Attempts,
+		"status_code": delivery.StatusCode,
+		"error":       delivery.Error,
+		"success":     delivery.Success,
+		"timestamp":   delivery.DeliveredAt,
+	})
+	return err
+}
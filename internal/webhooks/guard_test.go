@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	Assert "github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:2800:220:1::"} {
+		assert.True(IsPublicIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{
+		"127.0.0.1", "::1", "0.0.0.0", "::", "10.1.2.3", "172.16.0.1", "192.168.1.1",
+		"169.254.169.254", "100.64.0.1", "fd00::1", "fe80::1", "224.0.0.1", "::ffff:127.0.0.1",
+	} {
+		assert.False(IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestCheckUrl(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	ctx := context.Background()
+	assert.NoError(CheckUrl(ctx, "https://93.184.216.34/hooks"))
+	assert.NoError(CheckUrl(ctx, "https://[2606:2800:220:1::]:8443/hooks"))
+
+	for _, url := range []string{
+		"http://localhost:8080/hooks",
+		"http://api.localhost/hooks",
+		"http://127.0.0.1/hooks",
+		"http://[::1]/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hooks",
+		"http://0.0.0.0/hooks",
+	} {
+		assert.Equal(ErrPrivateAddress, CheckUrl(ctx, url), url)
+	}
+}
+
+func TestGuardedDialer(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+
+	_, err = guardedDialer(time.Second).Dial("tcp", listener.Addr().String())
+	assert.True(errors.Is(err, ErrPrivateAddress))
+}
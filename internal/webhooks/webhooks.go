@@ -0,0 +1,333 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-ScrumPoker-Event"
+	HeaderDelivery  = "X-ScrumPoker-Delivery"
+	HeaderTimestamp = "X-ScrumPoker-Timestamp"
+	HeaderSignature = "X-ScrumPoker-Signature"
+)
+
+var ErrClosed = errors.New("the webhook dispatcher is closed")
+
+// Payload is the body POSTed to the webhooks.
+type Payload struct {
+	Id        string      `json:"id"`
+	Event     string      `json:"event"`
+	RoomId    string      `json:"room_id"`
+	PinCode   string      `json:"pincode"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Source returns the webhooks registered for a room.
+type Source interface {
+	Webhooks(ctx context.Context, roomId string) ([]webhooks.Webhook, error)
+}
+
+// Log keeps the outcome of each delivery.
+type Log interface {
+	Record(ctx context.Context, roomId string, delivery webhooks.Delivery) error
+}
+
+type Config struct {
+	// Webhooks that receive the events of every room
+	Global []webhooks.Webhook
+
+	// How many times a delivery is attempted before giving up
+	MaxAttempts int
+
+	// Wait before the first retry, doubled on every new attempt
+	Backoff time.Duration
+
+	// Timeout of each attempt
+	Timeout time.Duration
+
+	// Whether the webhooks of the rooms may point to private addresses, e.g.
+	// for local development. The global webhooks always may.
+	AllowPrivate bool
+}
+
+type Dispatcher struct {
+	config Config
+	source Source
+	log    Log
+	// client delivers to the webhooks of the rooms, and trusted to the global
+	// ones, which come from the configuration
+	client  *http.Client
+	trusted *http.Client
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+func NewDispatcher(config Config, source Source, log Log) *Dispatcher {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	trusted := &http.Client{Timeout: config.Timeout}
+	client := trusted
+	if !config.AllowPrivate {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = guardedDialer(config.Timeout).DialContext
+		client = &http.Client{Timeout: config.Timeout, Transport: transport}
+	}
+
+	return &Dispatcher{
+		config:  config,
+		source:  source,
+		log:     log,
+		client:  client,
+		trusted: trusted,
+		done:    make(chan struct{}),
+	}
+}
+
+// Publish sends an event to the global webhooks and to the ones registered for
+// the room. It doesn't block: the deliveries happen in the background, as
+// children of the span of ctx, which they send along in the traceparent
+// header.
+func (d *Dispatcher) Publish(ctx context.Context, roomId, pinCode, event string, data interface{}) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	payload := Payload{
+		Id:        utils.UUID(),
+		Event:     event,
+		RoomId:    roomId,
+		PinCode:   pinCode,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+
+	// Only the span is kept: the request may be over by the time of the
+	// deliveries
+	traced := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.dispatch(traced, payload)
+	}()
+
+	return nil
+}
+
+// Running tells whether the dispatcher still accepts events.
+func (d *Dispatcher) Running() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return !d.closed
+}
+
+// Shutdown stops accepting events and waits for the pending deliveries. When
+// ctx expires first, the retries still waiting are abandoned.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+	}
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		d.abandon()
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) abandon() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	select {
+	case <-d.done:
+	default:
+		close(d.done)
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, payload Payload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	targets := append([]webhooks.Webhook{}, d.config.Global...)
+	global := len(targets)
+	if d.source != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+		roomWebhooks, err := d.source.Webhooks(ctx, payload.RoomId)
+		cancel()
+		if err == nil {
+			targets = append(targets, roomWebhooks...)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		if !target.Accepts(payload.Event) {
+			continue
+		}
+		client := d.client
+		if i < global {
+			client = d.trusted
+		}
+		wg.Add(1)
+		go func(target webhooks.Webhook, client *http.Client) {
+			defer wg.Done()
+			delivery := d.deliver(ctx, client, target, payload, body)
+			if d.log != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+				_ = d.log.Record(ctx, payload.RoomId, delivery)
+				cancel()
+			}
+		}(target, client)
+	}
+	wg.Wait()
+}
+
+// deliver POSTs the payload, retrying with exponential backoff on network
+// errors, 429 and 5xx responses.
+func (d *Dispatcher) deliver(ctx context.Context, client *http.Client, target webhooks.Webhook, payload Payload, body []byte) webhooks.Delivery {
+	delivery := webhooks.Delivery{
+		Id:        utils.UUID(),
+		WebhookId: target.Id,
+		Event:     payload.Event,
+		Url:       target.Url,
+	}
+
+	backoff := d.config.Backoff
+	for delivery.Attempts < d.config.MaxAttempts {
+		if delivery.Attempts > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-d.done:
+				delivery.Error = ErrClosed.Error()
+				delivery.DeliveredAt = time.Now().UTC()
+				return delivery
+			}
+			backoff *= 2
+		}
+		delivery.Attempts++
+
+		statusCode, err := d.post(ctx, client, target, delivery.Id, payload.Event, body)
+		delivery.StatusCode = statusCode
+		delivery.DeliveredAt = time.Now().UTC()
+		if err == nil && statusCode >= 200 && statusCode < 300 {
+			delivery.Success = true
+			delivery.Error = ""
+			return delivery
+		}
+
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Error = fmt.Sprintf("unexpected status code %d", statusCode)
+		}
+
+		if err == nil && statusCode != http.StatusTooManyRequests && statusCode < 500 {
+			return delivery
+		}
+	}
+
+	return delivery
+}
+
+// post makes an attempt at a delivery, traced as a client span.
+func (d *Dispatcher) post(ctx context.Context, client *http.Client, target webhooks.Webhook, deliveryId, event string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, target.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "webhook "+event, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(
+		semconv.HTTPMethodKey.String(http.MethodPost),
+		semconv.NetPeerNameKey.String(req.URL.Hostname()),
+		attribute.String("webhook.id", target.Id),
+		attribute.String("webhook.delivery_id", deliveryId),
+	)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ScrumPoker-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, deliveryId)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if len(target.Secret) > 0 {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(target.Secret, timestamp, body))
+	}
+	tracing.Propagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+	if res.StatusCode >= 400 {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", res.StatusCode))
+	}
+	return res.StatusCode, nil
+}
+
+// Sign computes the signature sent in the X-ScrumPoker-Signature header: the
+// hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers should compute it again and compare both with hmac.Equal.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,281 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu         sync.Mutex
+	webhooks   map[string][]webhooks.Webhook
+	deliveries []webhooks.Delivery
+}
+
+func (s *memoryStore) Webhooks(_ context.Context, roomId string) ([]webhooks.Webhook, error) {
+	return s.webhooks[roomId], nil
+}
+
+func (s *memoryStore) Record(_ context.Context, _ string, delivery webhooks.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+type received struct {
+	headers http.Header
+	body    []byte
+}
+
+func newReceiver(statusCodes ...int) (*httptest.Server, chan received, *int32) {
+	requests := make(chan received, 10)
+	calls := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- received{headers: r.Header, body: body}
+		if int(n) <= len(statusCodes) {
+			w.WriteHeader(statusCodes[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, requests, calls
+}
+
+func TestPublishSignedPayload(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, requests, _ := newReceiver()
+	defer server.Close()
+
+	store := &memoryStore{webhooks: map[string][]webhooks.Webhook{
+		"room": {{Id: "hook", Url: server.URL, Secret: "secret"}},
+	}}
+	dispatcher := NewDispatcher(Config{AllowPrivate: true}, store, store)
+
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventPlayerJoined, map[string]string{"name": "Ana"}))
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	req := <-requests
+	assert.Equal(webhooks.EventPlayerJoined, req.headers.Get(HeaderEvent))
+	assert.NotEmpty(req.headers.Get(HeaderDelivery))
+
+	expected := "sha256=" + Sign("secret", req.headers.Get(HeaderTimestamp), req.body)
+	assert.True(hmac.Equal([]byte(expected), []byte(req.headers.Get(HeaderSignature))))
+
+	payload := new(Payload)
+	assert.NoError(json.Unmarshal(req.body, payload))
+	assert.Equal("room", payload.RoomId)
+	assert.Equal("123456", payload.PinCode)
+	assert.Equal(webhooks.EventPlayerJoined, payload.Event)
+	assert.Equal(map[string]interface{}{"name": "Ana"}, payload.Data)
+
+	assert.Len(store.deliveries, 1)
+	assert.True(store.deliveries[0].Success)
+	assert.Equal(1, store.deliveries[0].Attempts)
+	assert.Equal(204, store.deliveries[0].StatusCode)
+	assert.Equal("hook", store.deliveries[0].WebhookId)
+}
+
+func TestPublishContinuesTheTrace(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, requests, _ := newReceiver()
+	defer server.Close()
+
+	dispatcher := NewDispatcher(Config{
+		Global: []webhooks.Webhook{{Id: "global", Url: server.URL}},
+	}, nil, nil)
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	defer otel.SetTracerProvider(previous)
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "POST /v1/rooms")
+	assert.NoError(dispatcher.Publish(ctx, "room", "123456", webhooks.EventRoomCreated, nil))
+	span.End()
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	req := <-requests
+	sc := trace.SpanContextFromContext(tracing.Propagator().Extract(context.Background(), propagation.HeaderCarrier(req.headers)))
+	assert.True(sc.IsValid())
+	assert.True(sc.IsSampled())
+	assert.Equal(span.SpanContext().TraceID(), sc.TraceID())
+	assert.NotEqual(span.SpanContext().SpanID(), sc.SpanID(), "the delivery has its own span")
+}
+
+func TestPublishRetriesWithBackoff(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, _, calls := newReceiver(500, 503)
+	defer server.Close()
+
+	store := new(memoryStore)
+	dispatcher := NewDispatcher(Config{
+		Global:  []webhooks.Webhook{{Id: "global", Url: server.URL}},
+		Backoff: 10 * time.Millisecond,
+	}, store, store)
+
+	start := time.Now()
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoomCreated, nil))
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	assert.Equal(int32(3), atomic.LoadInt32(calls))
+	assert.True(time.Since(start) >= 30*time.Millisecond)
+	assert.Len(store.deliveries, 1)
+	assert.True(store.deliveries[0].Success)
+	assert.Equal(3, store.deliveries[0].Attempts)
+}
+
+func TestPublishGivesUp(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, _, calls := newReceiver(500, 500, 500)
+	defer server.Close()
+
+	store := new(memoryStore)
+	dispatcher := NewDispatcher(Config{
+		Global:      []webhooks.Webhook{{Id: "global", Url: server.URL}},
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	}, store, store)
+
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoomCreated, nil))
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	assert.Equal(int32(3), atomic.LoadInt32(calls))
+	assert.False(store.deliveries[0].Success)
+	assert.Equal(500, store.deliveries[0].StatusCode)
+	assert.Equal("unexpected status code 500", store.deliveries[0].Error)
+}
+
+func TestPublishDoesNotRetryClientErrors(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, _, calls := newReceiver(404)
+	defer server.Close()
+
+	store := new(memoryStore)
+	dispatcher := NewDispatcher(Config{
+		Global:  []webhooks.Webhook{{Id: "global", Url: server.URL}},
+		Backoff: time.Millisecond,
+	}, store, store)
+
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoomCreated, nil))
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+	assert.False(store.deliveries[0].Success)
+	assert.Equal(1, store.deliveries[0].Attempts)
+}
+
+func TestPublishFiltersEvents(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, _, calls := newReceiver()
+	defer server.Close()
+
+	store := &memoryStore{webhooks: map[string][]webhooks.Webhook{
+		"room": {{Id: "hook", Url: server.URL, Events: []string{webhooks.EventRoundRevealed}}},
+	}}
+	dispatcher := NewDispatcher(Config{AllowPrivate: true}, store, store)
+
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventPlayerJoined, nil))
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoundRevealed, nil))
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+	assert.Len(store.deliveries, 1)
+	assert.Equal(webhooks.EventRoundRevealed, store.deliveries[0].Event)
+}
+
+func TestPublishRefusesPrivateAddresses(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, _, calls := newReceiver()
+	defer server.Close()
+
+	store := &memoryStore{webhooks: map[string][]webhooks.Webhook{
+		"room": {{Id: "hook", Url: server.URL}},
+	}}
+	dispatcher := NewDispatcher(Config{
+		Global:      []webhooks.Webhook{{Id: "global", Url: server.URL}},
+		MaxAttempts: 1,
+	}, store, store)
+
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoomCreated, nil))
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+	assert.Len(store.deliveries, 2)
+	for _, delivery := range store.deliveries {
+		if delivery.WebhookId == "global" {
+			assert.True(delivery.Success)
+		} else {
+			assert.False(delivery.Success)
+			assert.Contains(delivery.Error, ErrPrivateAddress.Error())
+		}
+	}
+}
+
+func TestPublishAfterShutdown(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	dispatcher := NewDispatcher(Config{}, nil, nil)
+	assert.True(dispatcher.Running())
+	assert.NoError(dispatcher.Shutdown(context.Background()))
+	assert.False(dispatcher.Running())
+
+	assert.Equal(ErrClosed, dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoomCreated, nil))
+}
+
+func TestShutdownAbandonsPendingRetries(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	server, _, _ := newReceiver(500, 500, 500)
+	defer server.Close()
+
+	store := new(memoryStore)
+	dispatcher := NewDispatcher(Config{
+		Global:  []webhooks.Webhook{{Id: "global", Url: server.URL}},
+		Backoff: time.Hour,
+	}, store, store)
+
+	assert.NoError(dispatcher.Publish(context.Background(), "room", "123456", webhooks.EventRoomCreated, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(context.DeadlineExceeded, dispatcher.Shutdown(ctx))
+
+	assert.Eventually(func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.deliveries) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(ErrClosed.Error(), store.deliveries[0].Error)
+}
@@ -0,0 +1,440 @@
+// Package client is a typed Go client of the Scrum Poker API, for bots and
+// integrations.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/pkg/models"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the version of the API the client talks to.
+const Version = "/v1"
+
+// Error is an error answered by the API.
+type Error struct {
+	Status    int
+	Code      models.ErrorCode
+	Message   string
+	Fields    []models.FieldError
+	RequestId string
+
+	retryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// IsCode tells whether err is an error of the API with the given code.
+func IsCode(err error, code models.ErrorCode) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == code
+}
+
+type Client struct {
+	baseUrl string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the HTTP client. It should have no timeout, or the
+// streams of events are cut: the deadlines of the calls come from their
+// contexts.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithRetries sets how many times a failed call is retried, waiting backoff
+// before the first retry and twice as long before each of the next ones.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New creates a client of the API served at baseUrl, e.g.
+// https://scrumpoker.example. By default a call is retried 3 times, from
+// 200ms on.
+func New(baseUrl string, opts ...Option) *Client {
+	c := &Client{
+		baseUrl: strings.TrimSuffix(baseUrl, "/") + Version,
+		http:    &http.Client{},
+		retries: 3,
+		backoff: 200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateRoom creates a room. The response has the token of the facilitator,
+// needed to start and reveal rounds.
+func (c *Client) CreateRoom(ctx context.Context, name string) (*models.RoomNewResponse, error) {
+	response := new(models.RoomNewResponse)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   "/rooms",
+		body:   models.RoomNewRequest{Name: name},
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Join adds a player to a room. The response has the token of the player,
+// needed to vote.
+func (c *Client) Join(ctx context.Context, pinCode, playerName string) (*models.RoomJoinResponse, error) {
+	response := new(models.RoomJoinResponse)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   "/rooms/" + url.PathEscape(pinCode) + "/join",
+		body:   models.RoomJoinRequest{PlayerName: playerName},
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListPlayers returns the players of a room in the order they joined,
+// reading every page of them.
+func (c *Client) ListPlayers(ctx context.Context, pinCode string) ([]models.Player, error) {
+	pls := make([]models.Player, 0)
+	cursor := ""
+	for {
+		path := "/rooms/" + url.PathEscape(pinCode) + "/players"
+		if len(cursor) > 0 {
+			path += "?cursor=" + url.QueryEscape(cursor)
+		}
+
+		var page []models.Player
+		header, err := c.call(ctx, request{
+			method:     "GET",
+			path:       path,
+			idempotent: true,
+		}, &page)
+		if err != nil {
+			return nil, err
+		}
+		pls = append(pls, page...)
+
+		if cursor = nextCursor(header); len(cursor) == 0 {
+			return pls, nil
+		}
+	}
+}
+
+// StartRound opens the next round of a room, for a story or for none when
+// storyId is empty.
+func (c *Client) StartRound(ctx context.Context, pinCode, facilitatorToken, storyId string) (*models.Round, error) {
+	round := new(models.Round)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   "/rooms/" + url.PathEscape(pinCode) + "/rounds",
+		token:  facilitatorToken,
+		body:   models.RoundNewRequest{StoryId: storyId},
+	}, round)
+	if err != nil {
+		return nil, err
+	}
+	return round, nil
+}
+
+// Vote records the card of a player in a round. Voting again replaces the
+// card while the round is hidden.
+func (c *Client) Vote(ctx context.Context, pinCode string, round int, playerId, playerToken, card string) error {
+	return c.VoteWithConfidence(ctx, pinCode, round, playerId, playerToken, card, 0)
+}
+
+// VoteWithConfidence records the card of a player in a round, with how
+// confident the player is, from 1 to 5, in the rooms that ask for it.
+func (c *Client) VoteWithConfidence(ctx context.Context, pinCode string, round int, playerId, playerToken, card string, confidence int) error {
+	return c.do(ctx, request{
+		method:     "POST",
+		path:       fmt.Sprintf("/rooms/%s/rounds/%d/votes", url.PathEscape(pinCode), round),
+		token:      playerToken,
+		body:       models.VoteRequest{PlayerId: playerId, Card: card, Confidence: confidence},
+		idempotent: true,
+	}, nil)
+}
+
+// Reveal shows the votes of a round.
+func (c *Client) Reveal(ctx context.Context, pinCode, facilitatorToken string, round int) (*models.Summary, error) {
+	summary := new(models.Summary)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   fmt.Sprintf("/rooms/%s/rounds/%d/reveal", url.PathEscape(pinCode), round),
+		token:  facilitatorToken,
+	}, summary)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ReVote opens the next attempt at the story of a revealed round, after its
+// votes were discussed. Only the latest round can be voted again.
+func (c *Client) ReVote(ctx context.Context, pinCode, facilitatorToken string, round int) (*models.Round, error) {
+	next := new(models.Round)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   fmt.Sprintf("/rooms/%s/rounds/%d/revote", url.PathEscape(pinCode), round),
+		token:  facilitatorToken,
+	}, next)
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// EstimateStory sets the final estimate of a story.
+func (c *Client) EstimateStory(ctx context.Context, pinCode, facilitatorToken, storyId, estimate string) (*models.Story, error) {
+	story := new(models.Story)
+	err := c.do(ctx, request{
+		method:     "PUT",
+		path:       fmt.Sprintf("/rooms/%s/stories/%s/estimate", url.PathEscape(pinCode), url.PathEscape(storyId)),
+		token:      facilitatorToken,
+		body:       models.StoryEstimateRequest{Estimate: estimate},
+		idempotent: true,
+	}, story)
+	if err != nil {
+		return nil, err
+	}
+	return story, nil
+}
+
+// OpenPoll opens a poll in a room.
+func (c *Client) OpenPoll(ctx context.Context, pinCode, facilitatorToken string, poll models.PollNewRequest) (*models.Poll, error) {
+	opened := new(models.Poll)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   "/rooms/" + url.PathEscape(pinCode) + "/polls",
+		token:  facilitatorToken,
+		body:   poll,
+	}, opened)
+	if err != nil {
+		return nil, err
+	}
+	return opened, nil
+}
+
+// GetPoll reads a poll, with its results once it is closed.
+func (c *Client) GetPoll(ctx context.Context, pinCode, pollId string) (*models.Poll, error) {
+	poll := new(models.Poll)
+	err := c.do(ctx, request{
+		method:     "GET",
+		path:       fmt.Sprintf("/rooms/%s/polls/%s", url.PathEscape(pinCode), url.PathEscape(pollId)),
+		idempotent: true,
+	}, poll)
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// VotePoll records the choices of a player in a poll. Voting again replaces
+// them while the poll is open.
+func (c *Client) VotePoll(ctx context.Context, pinCode, pollId, playerId, playerToken string, choices ...string) error {
+	return c.do(ctx, request{
+		method:     "POST",
+		path:       fmt.Sprintf("/rooms/%s/polls/%s/votes", url.PathEscape(pinCode), url.PathEscape(pollId)),
+		token:      playerToken,
+		body:       models.PollVoteRequest{PlayerId: playerId, Choices: choices},
+		idempotent: true,
+	}, nil)
+}
+
+// ClosePoll ends the votes of a poll and returns its results.
+func (c *Client) ClosePoll(ctx context.Context, pinCode, facilitatorToken, pollId string) (*models.Poll, error) {
+	poll := new(models.Poll)
+	err := c.do(ctx, request{
+		method: "POST",
+		path:   fmt.Sprintf("/rooms/%s/polls/%s/close", url.PathEscape(pinCode), url.PathEscape(pollId)),
+		token:  facilitatorToken,
+	}, poll)
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+type request struct {
+	method string
+	path   string
+	token  string
+	body   interface{}
+	// Whether repeating the call is harmless, so it can be retried when its
+	// outcome is unknown
+	idempotent bool
+}
+
+// do sends the request and decodes the response into out.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	_, err := c.call(ctx, req, out)
+	return err
+}
+
+// call is do, also returning the headers of the response.
+func (c *Client) call(ctx context.Context, req request, out interface{}) (http.Header, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	var header http.Header
+	err := c.retry(ctx, req.idempotent, func() error {
+		res, err := c.send(ctx, req, body, "application/json")
+		if err != nil {
+			return err
+		}
+		header = res.Header
+		return decode(res, out)
+	})
+	return header, err
+}
+
+// nextCursor reads the cursor of the next page of a listing from its Link
+// header, or is empty on the last page.
+func nextCursor(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			if !strings.Contains(link, `rel="next"`) {
+				continue
+			}
+			start, end := strings.Index(link, "<"), strings.Index(link, ">")
+			if start < 0 || end < start {
+				continue
+			}
+			if target, err := url.Parse(link[start+1 : end]); err == nil {
+				return target.Query().Get("cursor")
+			}
+		}
+	}
+	return ""
+}
+
+// retry calls call until it succeeds, it fails in a way that retrying won't
+// fix, or the retries run out.
+func (c *Client) retry(ctx context.Context, idempotent bool, call func() error) error {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= c.retries || !retryable(ctx, err, idempotent) {
+			return err
+		}
+
+		wait := backoff
+		if e, ok := err.(*Error); ok && e.retryAfter > 0 {
+			wait = e.retryAfter
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, accept string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseUrl+req.path, reader)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", accept)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if len(req.token) > 0 {
+		httpReq.Header.Set("Authorization", "Bearer "+req.token)
+	}
+
+	res, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		defer res.Body.Close()
+		return nil, apiError(res)
+	}
+	return res, nil
+}
+
+func decode(res *http.Response, out interface{}) error {
+	defer res.Body.Close()
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func apiError(res *http.Response) *Error {
+	e := &Error{Status: res.StatusCode}
+
+	body := new(models.Error)
+	if err := json.NewDecoder(res.Body).Decode(body); err == nil && len(body.ErrorCode) > 0 {
+		e.Code = models.ErrorCode(body.ErrorCode)
+		e.Message = body.Message
+		e.Fields = body.Fields
+		e.RequestId = body.RequestId
+	} else {
+		// Not answered by the API itself, e.g. by a proxy
+		e.Message = http.StatusText(res.StatusCode)
+	}
+
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return e
+}
+
+// retryable tells whether a failed call may succeed if sent again. The API
+// rejects the calls it answers with 429 or 503 before doing anything, but a
+// 502, a 504 or a broken connection leaves the outcome unknown, so those
+// calls are only sent again when repeating them is harmless.
+func retryable(ctx context.Context, err error, idempotent bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	e, ok := err.(*Error)
+	if !ok {
+		return idempotent
+	}
+
+	switch e.Status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
@@ -0,0 +1,325 @@
+package client
+
+import (
+	"cloud.google.com/go/firestore"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golobby/container"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/config"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/controllers/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/deadline"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/di"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/pkg/models"
+	"golang.org/x/net/nettest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serve runs an app with the error handler and the deadline of the API and
+// returns its URL.
+func serve(t *testing.T, register func(router fiber.Router)) string {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+		// Closes the connections the client keeps, so the app can shut down
+		IdleTimeout: 100 * time.Millisecond,
+	})
+	app.Use(deadline.New(5 * time.Second))
+	register(app.Group(Version))
+
+	listener, err := nettest.NewLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = app.Listener(listener)
+	}()
+	t.Cleanup(func() {
+		_ = app.Shutdown()
+	})
+
+	return fmt.Sprintf("http://%s", listener.Addr())
+}
+
+func newClient(url string) *Client {
+	return New(url, WithRetries(2, time.Millisecond))
+}
+
+func TestPlayRound(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	conf := config.Default()
+	conf.Firestore.ProjectId = "scrumpoker"
+	conf.Firestore.EmulatorHost = os.Getenv("FIRESTORE_EMULATOR_HOST")
+	if len(conf.Firestore.EmulatorHost) == 0 {
+		// Without the emulator the calls fail instead of panicking
+		conf.Firestore.EmulatorHost = "127.0.0.1:1"
+	}
+	assert.NoError(di.SetupDependencies(conf))
+	db := new(firestore.Client)
+	container.Make(&db)
+	defer db.Close()
+
+	client := newClient(serve(t, rooms.Register))
+	ctx := context.Background()
+
+	room, err := client.CreateRoom(ctx, "Sprint 42")
+	if !assert.NoError(err) {
+		return
+	}
+
+	subscription, err := client.Subscribe(ctx, room.PinCode)
+	if !assert.NoError(err) {
+		return
+	}
+	defer subscription.Close()
+
+	player, err := client.Join(ctx, room.PinCode, "Ana")
+	assert.NoError(err)
+
+	pls, err := client.ListPlayers(ctx, room.PinCode)
+	assert.NoError(err)
+	assert.Len(pls, 1)
+
+	round, err := client.StartRound(ctx, room.PinCode, room.FacilitatorToken, "")
+	assert.NoError(err)
+	assert.NoError(client.Vote(ctx, room.PinCode, round.Number, player.PlayerId, player.PlayerToken, "8"))
+
+	summary, err := client.Reveal(ctx, room.PinCode, room.FacilitatorToken, round.Number)
+	assert.NoError(err)
+	assert.Equal("8", summary.Votes[0].Value)
+
+	names := make([]string, 0, 4)
+	for len(names) < 4 {
+		select {
+		case event := <-subscription.Events():
+			names = append(names, event.Event)
+		case <-time.After(5 * time.Second):
+			assert.Fail("missing events", "got %v", names)
+			return
+		}
+	}
+	assert.Equal([]string{models.EventPlayerJoined, models.EventRoundStarted, models.EventVoteCast, models.EventRoundRevealed}, names)
+}
+
+func TestErrorOfTheApi(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+			return apierror.New(apierror.RoomNotFound, "room not found")
+		})
+	}))
+
+	_, err := client.ListPlayers(context.Background(), "123456")
+
+	var apiErr *Error
+	if assert.True(errors.As(err, &apiErr)) {
+		assert.Equal(404, apiErr.Status)
+		assert.Equal("room not found", apiErr.Message)
+	}
+	assert.True(IsCode(err, models.ErrRoomNotFound))
+}
+
+func TestRetryUnavailable(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	var calls int32
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Post("/rooms", func(c *fiber.Ctx) error {
+			// As answered by the load balancer while the service starts
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return c.Status(503).SendString("Service Unavailable")
+			}
+			return c.JSON(models.RoomNewResponse{RoomId: "room-1"})
+		})
+	}))
+
+	room, err := client.CreateRoom(context.Background(), "Room")
+
+	assert.NoError(err)
+	assert.Equal("room-1", room.RoomId)
+	assert.Equal(int32(3), calls)
+}
+
+func TestRetryOnlyWhatIsHarmless(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	var creates, lists int32
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Post("/rooms", func(c *fiber.Ctx) error {
+			atomic.AddInt32(&creates, 1)
+			return apierror.New(apierror.Timeout, "the storage took too long to answer")
+		})
+		router.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+			atomic.AddInt32(&lists, 1)
+			return apierror.New(apierror.Timeout, "the storage took too long to answer")
+		})
+	}))
+
+	_, err := client.CreateRoom(context.Background(), "Room")
+	assert.True(IsCode(err, models.ErrTimeout))
+	assert.Equal(int32(1), creates, "the room may have been created")
+
+	_, err = client.ListPlayers(context.Background(), "123456")
+	assert.True(IsCode(err, models.ErrTimeout))
+	assert.Equal(int32(3), lists)
+}
+
+func TestNoRetryOfClientErrors(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	var calls int32
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Post("/rooms/:pincode/rounds/:n/votes", func(c *fiber.Ctx) error {
+			atomic.AddInt32(&calls, 1)
+			return apierror.Invalid("card", "the card is not part of the deck")
+		})
+	}))
+
+	err := client.Vote(context.Background(), "123456", 1, "player-1", "token", "4")
+
+	var apiErr *Error
+	if assert.True(errors.As(err, &apiErr)) {
+		assert.Equal(models.ErrValidationFailed, apiErr.Code)
+		assert.Equal([]models.FieldError{{Field: "card", Message: "the card is not part of the deck"}}, apiErr.Fields)
+	}
+	assert.Equal(int32(1), calls)
+}
+
+func TestRetryStopsWithTheContext(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	url := serve(t, func(router fiber.Router) {
+		router.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+			return c.Status(503).SendString("Service Unavailable")
+		})
+	})
+	client := New(url, WithRetries(10, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.ListPlayers(ctx, "123456")
+
+	var apiErr *Error
+	if assert.True(errors.As(err, &apiErr), "the last error is returned") {
+		assert.Equal(503, apiErr.Status)
+		assert.Equal("Service Unavailable", apiErr.Message)
+	}
+	assert.Less(int64(time.Since(start)), int64(time.Second))
+}
+
+func TestListPlayersPages(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Get("/rooms/:pincode/players", func(c *fiber.Ctx) error {
+			if c.Query("cursor") == "" {
+				c.Append(fiber.HeaderLink, `</v1/rooms/123456/players?limit=1&cursor=next-1>; rel="next"`)
+				return c.JSON([]models.Player{{Id: "player-1", Name: "Ana"}})
+			}
+			assert.Equal("next-1", c.Query("cursor"))
+			return c.JSON([]models.Player{{Id: "player-2", Name: "Bruno"}})
+		})
+	}))
+
+	pls, err := client.ListPlayers(context.Background(), "123456")
+
+	assert.NoError(err)
+	assert.Equal([]models.Player{{Id: "player-1", Name: "Ana"}, {Id: "player-2", Name: "Bruno"}}, pls)
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	var connections int32
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Get("/rooms/:pincode/events", func(c *fiber.Ctx) error {
+			n := atomic.AddInt32(&connections, 1)
+			c.Set(fiber.HeaderContentType, "text/event-stream")
+			// Each stream sends one event and ends
+			return c.SendString(fmt.Sprintf(": connected\n\nid: %d\nevent: player.joined\ndata: {\"id\":\"%d\",\"event\":\"player.joined\",\"data\":{\"name\":\"Ana\"}}\n\n", n, n))
+		})
+	}))
+
+	subscription, err := client.Subscribe(context.Background(), "123456")
+	if !assert.NoError(err) {
+		return
+	}
+
+	for _, id := range []string{"1", "2"} {
+		event := <-subscription.Events()
+		assert.Equal(id, event.Id)
+		assert.Equal(models.EventPlayerJoined, event.Event)
+
+		player := new(models.Player)
+		assert.NoError(event.Decode(player))
+		assert.Equal("Ana", player.Name)
+	}
+
+	subscription.Close()
+	for range subscription.Events() {
+	}
+	assert.NoError(subscription.Err())
+}
+
+func TestSubscribeWaitsAfterShutdown(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	var connections int32
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Get("/rooms/:pincode/events", func(c *fiber.Ctx) error {
+			n := atomic.AddInt32(&connections, 1)
+			c.Set(fiber.HeaderContentType, "text/event-stream")
+			// The first stream is ended by the shutdown of the server
+			if n == 1 {
+				return c.SendString(": connected\n\nretry: 300\nid: 1\nevent: server.shutdown\ndata: {\"id\":\"1\",\"event\":\"server.shutdown\",\"data\":null}\n\n")
+			}
+			return c.SendString(fmt.Sprintf(": connected\n\nid: %d\nevent: player.joined\ndata: {\"id\":\"%d\",\"event\":\"player.joined\",\"data\":{\"name\":\"Ana\"}}\n\n", n, n))
+		})
+	}))
+
+	start := time.Now()
+	subscription, err := client.Subscribe(context.Background(), "123456")
+	if !assert.NoError(err) {
+		return
+	}
+	defer subscription.Close()
+
+	event := <-subscription.Events()
+	assert.Equal("2", event.Id, "the shutdown is not an event of the room")
+	assert.True(time.Since(start) >= 300*time.Millisecond, "the client waits as long as the server asked")
+}
+
+func TestSubscribeRoomNotFound(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	client := newClient(serve(t, func(router fiber.Router) {
+		router.Get("/rooms/:pincode/events", func(c *fiber.Ctx) error {
+			return apierror.New(apierror.RoomNotFound, "room not found")
+		})
+	}))
+
+	_, err := client.Subscribe(context.Background(), "123456")
+
+	assert.True(IsCode(err, models.ErrRoomNotFound))
+}
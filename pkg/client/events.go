@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is something that happened in a room. Data depends on the event: a
+// models.Player for models.EventPlayerJoined, a models.Round for
+// models.EventRoundStarted, a models.Ballot for models.EventVoteCast, a
+// models.Summary for models.EventRoundRevealed, a models.Story for
+// models.EventStoryEstimated, a models.Poll for models.EventPollOpened and
+// models.EventPollClosed and a models.PollBallot for models.EventPollVoted.
+type Event struct {
+	Id        string          `json:"id"`
+	Event     string          `json:"event"`
+	RoomId    string          `json:"room_id"`
+	PinCode   string          `json:"pincode"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Decode decodes the data of the event into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// The last event of a stream ended by the server when it shuts down. It is not
+// an event of the room, so the subscriptions keep it to themselves.
+const eventServerShutdown = "server.shutdown"
+
+// Subscription streams the events of a room. When the stream ends, e.g.
+// because the server restarts, it reconnects by itself, when the server
+// tells it to if it does; the events that happen meanwhile are missed.
+type Subscription struct {
+	events chan Event
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// Events returns the events of the room. The channel is closed when the
+// subscription is closed, when its context is done, or when it can't
+// reconnect, which Err tells.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err tells why the subscription ended, once Events is closed. It is nil when
+// the subscription was closed.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.cancel()
+}
+
+// Subscribe streams the events of a room from now on. It fails when the
+// first connection fails, e.g. because the room doesn't exist.
+func (c *Client) Subscribe(ctx context.Context, pinCode string) (*Subscription, error) {
+	return c.subscribe(ctx, request{
+		method:     "GET",
+		path:       "/rooms/" + url.PathEscape(pinCode) + "/events",
+		idempotent: true,
+	})
+}
+
+// SubscribeAs streams the events of a room like Subscribe, for a player, who
+// is online while the subscription is connected.
+func (c *Client) SubscribeAs(ctx context.Context, pinCode, playerId, playerToken string) (*Subscription, error) {
+	return c.subscribe(ctx, request{
+		method:     "GET",
+		path:       "/rooms/" + url.PathEscape(pinCode) + "/events?player_id=" + url.QueryEscape(playerId),
+		token:      playerToken,
+		idempotent: true,
+	})
+}
+
+func (c *Client) subscribe(ctx context.Context, req request) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	body, err := c.connect(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &Subscription{
+		events: make(chan Event),
+		cancel: cancel,
+	}
+
+	go func() {
+		defer close(s.events)
+		defer cancel()
+
+		for {
+			wait := read(ctx, body, s.events)
+			_ = body.Close()
+			if wait <= 0 {
+				wait = c.backoff
+			}
+
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+
+			body, err = c.connect(ctx, req)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.mu.Lock()
+					s.err = err
+					s.mu.Unlock()
+				}
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// connect opens the stream of events, retrying like the other calls.
+func (c *Client) connect(ctx context.Context, req request) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := c.retry(ctx, req.idempotent, func() error {
+		res, err := c.send(ctx, req, nil, "text/event-stream")
+		if err != nil {
+			return err
+		}
+		body = res.Body
+		return nil
+	})
+	return body, err
+}
+
+// read parses the Server-Sent Events of body until it ends, sending the
+// events of the room to out. It returns how long the server asked to wait
+// before reconnecting, if it did.
+func read(ctx context.Context, body io.Reader, out chan<- Event) time.Duration {
+	reader := bufio.NewReader(body)
+
+	var retry time.Duration
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return retry
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			// A blank line ends the event
+			if data.Len() == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err == nil && event.Event != eventServerShutdown {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return retry
+				}
+			}
+			data.Reset()
+		case strings.HasPrefix(line, "retry:"):
+			if ms, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "retry:"))); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// The comments and the other fields are not needed: the data has
+		// the name and the ID of the event
+	}
+}
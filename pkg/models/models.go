@@ -0,0 +1,91 @@
+// Package models exposes the types of the API to the Go programs that use
+// it. They are aliases of the types the server itself uses, so both sides
+// always agree on the JSON.
+package models
+
+import (
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/apierror"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/players"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/polls"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rooms"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/rounds"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/stories"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/models/webhooks"
+)
+
+type (
+	Error      = models.Error
+	FieldError = models.FieldError
+
+	Room             = rooms.Room
+	Settings         = rooms.Settings
+	RoomNewRequest   = rooms.RoomNewRequest
+	RoomNewResponse  = rooms.RoomNewResponse
+	RoomJoinRequest  = rooms.RoomJoinRequest
+	RoomJoinResponse = rooms.RoomJoinResponse
+
+	Player = players.Player
+
+	Round           = rounds.Round
+	RoundNewRequest = rounds.RoundNewRequest
+	VoteRequest     = rounds.VoteRequest
+	Ballot          = rounds.Ballot
+	Vote            = rounds.Vote
+	Summary         = rounds.Summary
+	Confidence      = rounds.Confidence
+
+	Story                = stories.Story
+	StoryEstimateRequest = stories.StoryEstimateRequest
+
+	Poll            = polls.Poll
+	PollResult      = polls.Result
+	PollNewRequest  = polls.PollNewRequest
+	PollVoteRequest = polls.PollVoteRequest
+	PollBallot      = polls.PollBallot
+
+	Webhook = webhooks.Webhook
+)
+
+// ErrorCode is the machine-readable code of an error, in the "error" field
+// of the body.
+type ErrorCode = apierror.Code
+
+const (
+	ErrRoomNotFound     = apierror.RoomNotFound
+	ErrNotFound         = apierror.NotFound
+	ErrMethodNotAllowed = apierror.MethodNotAllowed
+	ErrInvalidBody      = apierror.InvalidBody
+	ErrValidationFailed = apierror.ValidationFailed
+	ErrRoomClosed       = apierror.RoomClosed
+	ErrConflict         = apierror.Conflict
+	ErrUnauthorized     = apierror.Unauthorized
+	ErrRateLimited      = apierror.RateLimited
+	ErrTimeout          = apierror.Timeout
+	ErrCanceled         = apierror.Canceled
+	ErrInternal         = apierror.Internal
+)
+
+// The events of a room, as sent to the webhooks and streamed to the
+// subscribers.
+const (
+	EventRoomCreated    = webhooks.EventRoomCreated
+	EventPlayerJoined   = webhooks.EventPlayerJoined
+	EventRoundStarted   = webhooks.EventRoundStarted
+	EventVoteCast       = webhooks.EventVoteCast
+	EventRoundRevealed  = webhooks.EventRoundRevealed
+	EventStoryEstimated = webhooks.EventStoryEstimated
+	EventPollOpened     = webhooks.EventPollOpened
+	EventPollVoted      = webhooks.EventPollVoted
+	EventPollClosed     = webhooks.EventPollClosed
+)
+
+// The kinds of polls
+const (
+	PollSingle = polls.Single
+	PollMulti  = polls.Multi
+	PollYesNo  = polls.YesNo
+)
+
+// DefaultDeck is the deck of cards of the rooms.
+var DefaultDeck = rounds.DefaultDeck
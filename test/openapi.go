@@ -0,0 +1,97 @@
+package test
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/openapi"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Contract checks the requests an app answers, and its responses, against
+// the OpenAPI document of the API. A request the document doesn't allow only
+// breaks the contract when the app accepts it; the tests send some on
+// purpose.
+type Contract struct {
+	validator *openapi.Validator
+	prefix    string
+
+	mu         sync.Mutex
+	violations []string
+}
+
+// NewContract checks an app against the document. The routes of the app are
+// matched as if they were registered under prefix, e.g. /v1.
+func NewContract(document []byte, prefix string) (*Contract, error) {
+	validator, err := openapi.NewValidator(document)
+	if err != nil {
+		return nil, err
+	}
+	return &Contract{validator: validator, prefix: prefix}, nil
+}
+
+// Handler is the middleware that checks the requests and the responses. The
+// errors are answered by the error handler of the app, to check them too.
+func (c *Contract) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		method := ctx.Method()
+		path := c.prefix + ctx.Path()
+
+		query := url.Values{}
+		ctx.Context().QueryArgs().VisitAll(func(key, value []byte) {
+			query.Add(string(key), string(value))
+		})
+		requestErr := c.validator.ValidateRequest(method, path, query, string(ctx.Request().Header.ContentType()), ctx.Body())
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().Config().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := ctx.Response().StatusCode()
+		if requestErr == openapi.ErrUndocumented {
+			if status != fiber.StatusNotFound && status != fiber.StatusMethodNotAllowed {
+				c.record(method, path, requestErr)
+			}
+			return nil
+		}
+		if requestErr != nil && status < 400 {
+			c.record(method, path, fmt.Errorf("accepted: %w", requestErr))
+		}
+
+		var body []byte
+		if !ctx.Response().IsBodyStream() {
+			// The streams are sent after the middlewares return
+			body = ctx.Response().Body()
+		}
+		if err := c.validator.ValidateResponse(method, path, status, string(ctx.Response().Header.ContentType()), body); err != nil {
+			c.record(method, path, fmt.Errorf("answered %d: %w", status, err))
+		}
+
+		return nil
+	}
+}
+
+func (c *Contract) record(method, path string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.violations = append(c.violations, fmt.Sprintf("%s %s %s", method, path, err))
+}
+
+// Violations returns how the app broke the contract so far.
+func (c *Contract) Violations() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.violations...)
+}
+
+// Report describes the violations, or is empty when there are none.
+func (c *Contract) Report() string {
+	violations := c.Violations()
+	if len(violations) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("the API broke its OpenAPI document %d times:\n%s", len(violations), strings.Join(violations, "\n"))
+}
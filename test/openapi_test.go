@@ -0,0 +1,118 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	Assert "github.com/stretchr/testify/assert"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/openapi"
+	"github.com/thiagopereiramartinez/scrumpoker-run.api/internal/utils"
+	"net/http"
+	"testing"
+)
+
+const swagger = `{
+	"swagger": "2.0",
+	"paths": {
+		"/v1/rooms": {
+			"post": {
+				"consumes": ["application/json"],
+				"produces": ["application/json"],
+				"parameters": [{"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/rooms.RoomNewRequest"}}],
+				"responses": {
+					"200": {"description": "OK", "schema": {"$ref": "#/definitions/rooms.RoomNewResponse"}},
+					"400": {"description": "", "schema": {"$ref": "#/definitions/models.Error"}}
+				}
+			}
+		}
+	},
+	"definitions": {
+		"models.Error": {"type": "object", "properties": {"code": {"type": "integer"}, "error": {"type": "string"}, "message": {"type": "string"}}},
+		"rooms.RoomNewRequest": {"type": "object", "properties": {"name": {"type": "string"}}},
+		"rooms.RoomNewResponse": {"type": "object", "properties": {"room_id": {"type": "string"}}}
+	}
+}`
+
+func newContract(assert *Assert.Assertions, handler fiber.Handler) (*Contract, *fiber.App) {
+	doc, err := openapi.Convert([]byte(swagger))
+	assert.NoError(err)
+	document, err := json.Marshal(doc)
+	assert.NoError(err)
+
+	contract, err := NewContract(document, "/v1")
+	assert.NoError(err)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          utils.ErrorHandler,
+	})
+	app.Use(contract.Handler())
+	app.Post("/rooms", handler)
+
+	return contract, app
+}
+
+func send(app *fiber.App, body string) *http.Response {
+	req, _ := http.NewRequest("POST", "/rooms", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	res, _ := app.Test(req)
+	return res
+}
+
+func TestContractKept(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	contract, app := newContract(assert, func(c *fiber.Ctx) error {
+		if len(c.Body()) == 0 {
+			return fiber.ErrBadRequest
+		}
+		return c.JSON(fiber.Map{"room_id": "room-1"})
+	})
+
+	assert.Equal(200, send(app, `{"name": "Room"}`).StatusCode)
+	assert.Equal(400, send(app, ``).StatusCode, "the errors are answered by the error handler")
+
+	assert.Empty(contract.Violations())
+	assert.Empty(contract.Report())
+}
+
+func TestContractBroken(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	contract, app := newContract(assert, func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"id": "room-1"})
+	})
+
+	send(app, `{"name": "Room"}`)
+	send(app, `{"room_name": "Room"}`)
+
+	assert.Equal([]string{
+		"POST /v1/rooms answered 200: the response body has id, which is not documented",
+		"POST /v1/rooms accepted: the request body has room_name, which is not documented",
+		"POST /v1/rooms answered 200: the response body has id, which is not documented",
+	}, contract.Violations())
+	assert.Contains(contract.Report(), "the API broke its OpenAPI document 3 times")
+}
+
+func TestContractUndocumented(t *testing.T) {
+
+	assert := Assert.New(t)
+
+	contract, app := newContract(assert, func(c *fiber.Ctx) error {
+		return c.SendStatus(204)
+	})
+	app.Get("/rooms", func(c *fiber.Ctx) error {
+		return c.SendString("rooms")
+	})
+
+	req, _ := http.NewRequest("GET", "/rooms", nil)
+	_, _ = app.Test(req)
+	req, _ = http.NewRequest("GET", "/nothing", nil)
+	_, _ = app.Test(req)
+
+	assert.Equal([]string{
+		"GET /v1/rooms the operation is not documented",
+	}, contract.Violations(), "only the routes that answer")
+}